@@ -0,0 +1,37 @@
+package broker
+
+import "net/http"
+
+// Authenticator adds credentials to a request to the Pact Broker.
+type Authenticator interface {
+	Authenticate(req *http.Request)
+}
+
+// AuthenticatorFunc is a function that adds credentials to a request.
+type AuthenticatorFunc func(req *http.Request)
+
+// Authenticate calls the function with the request.
+func (f AuthenticatorFunc) Authenticate(req *http.Request) {
+	f(req)
+}
+
+// BasicAuth authenticates with a username and password.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate sets the basic authentication credentials of the request.
+func (a BasicAuth) Authenticate(req *http.Request) {
+	req.SetBasicAuth(a.Username, a.Password)
+}
+
+// BearerAuth authenticates with a token, such as a Pactflow API token.
+type BearerAuth struct {
+	Token string
+}
+
+// Authenticate sets the bearer token of the request.
+func (a BearerAuth) Authenticate(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+}
@@ -0,0 +1,314 @@
+// Package broker is a client for the Pact Broker API.
+//
+// The client navigates the HAL index of a Broker by relation name, so that it
+// follows the Broker's own URLs rather than assuming them. Brokers that don't
+// advertise a relation are sent to its standard path instead.
+package broker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is returned when the Broker rejects the credentials of a
+	// request with a 401 or 403.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrNotFound is returned when a resource does not exist in the Broker.
+	ErrNotFound = errors.New("not found")
+)
+
+// Error is an unexpected response from the Pact Broker.
+type Error struct {
+	// StatusCode of the response.
+	StatusCode int
+
+	// Body of the response, which usually describes the error.
+	Body string
+}
+
+func (e *Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected response from pact broker: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return e.Body
+}
+
+// Client is a client for a Pact Broker.
+type Client struct {
+	// BrokerURL is the base URL of the Pact Broker e.g. https://broker.example.com.
+	BrokerURL string
+
+	// Auth authenticates each request to the Pact Broker. Optional.
+	Auth Authenticator
+
+	// HTTPClient used to communicate with the Pact Broker.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// DryRun logs the requests that would change the Broker, such as
+	// publishing a Pact, instead of sending them. GET requests are still
+	// sent.
+	DryRun bool
+
+	// Timeout of each request to the Broker, including reading its response.
+	// Defaults to no timeout.
+	Timeout time.Duration
+
+	// MaxRetries of a request that fails with a connection error or a 5xx
+	// response. Only idempotent requests, such as GET and PUT, are retried.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, which doubles with
+	// each retry. Defaults to 1 second.
+	RetryBackoff time.Duration
+
+	// index is the HAL index of the Broker, once fetched. Guarded by indexMu,
+	// as the client may be shared by goroutines. Both are shared with the
+	// copies made by WithContext.
+	index   *Resource
+	indexMu *sync.Mutex
+
+	// ctx of the requests to the Broker.
+	ctx context.Context
+}
+
+// WithContext returns a shallow copy of the client that sends its requests
+// with the given context, so that they can be cancelled.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c.indexLock()
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// Context returns the context of the requests of the client, which defaults
+// to the background context.
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Link finds the URL of a relation in the HAL index of the Broker, expanding
+// any template parameters in it e.g. {provider}.
+func (c *Client) Link(rel string, params map[string]string) (string, error) {
+	index, err := c.Index()
+	if err != nil {
+		return "", err
+	}
+
+	link, ok := index.Links.Find(rel)
+	if !ok {
+		path, ok := defaultLinks[rel]
+		if !ok {
+			return "", fmt.Errorf("pact broker does not support the relation '%s'", rel)
+		}
+		log.Printf("[DEBUG] pact broker: relation '%s' not in index, using %s", rel, path)
+		link = Link{Href: strings.TrimSuffix(c.BrokerURL, "/") + path, Templated: true}
+	}
+
+	return link.Expand(params)
+}
+
+// newIndexMu guards the creation of the index mutex of each client.
+var newIndexMu sync.Mutex
+
+// indexLock returns the mutex guarding the cached index of the client,
+// creating it on first use.
+func (c *Client) indexLock() *sync.Mutex {
+	newIndexMu.Lock()
+	defer newIndexMu.Unlock()
+	if c.indexMu == nil {
+		c.indexMu = &sync.Mutex{}
+	}
+	return c.indexMu
+}
+
+// Index fetches the HAL index of the Broker, which is cached by the client.
+//
+// A Broker that has no HAL index, responding with a 404 or a body that is not
+// JSON, is given an empty one so that its standard paths are used. Any other
+// error, such as a 5xx response, is returned.
+func (c *Client) Index() (*Resource, error) {
+	mu := c.indexLock()
+	mu.Lock()
+	defer mu.Unlock()
+	if c.index != nil {
+		return c.index, nil
+	}
+
+	if c.BrokerURL == "" {
+		return nil, errors.New("pact broker URL is mandatory")
+	}
+
+	index := &Resource{}
+	data, err := c.fetch(http.MethodGet, c.BrokerURL, nil)
+	switch {
+	case err == ErrNotFound:
+		log.Println("[DEBUG] pact broker: no HAL index, using standard paths")
+	case err != nil:
+		return nil, err
+	default:
+		if err = json.Unmarshal(data, index); err != nil {
+			log.Println("[DEBUG] pact broker: unable to read HAL index, using standard paths:", err)
+			index = &Resource{}
+		}
+	}
+
+	c.index = index
+	return index, nil
+}
+
+// Get fetches the resource at a relation of the index, decoding it into v.
+func (c *Client) Get(rel string, params map[string]string, v interface{}) error {
+	href, err := c.Link(rel, params)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(http.MethodGet, href, nil, v)
+}
+
+// Send sends a request to the Broker, decoding any response body into v.
+//
+// A 401 or 403 response is returned as ErrUnauthorized, a 404 as ErrNotFound
+// and any other unsuccessful response as an *Error.
+func (c *Client) Send(method string, href string, body []byte, v interface{}) error {
+	if c.DryRun && method != http.MethodGet {
+		log.Printf("[INFO] pact broker: dry run: %s %s (%s)", method, href, Digest(body))
+		return nil
+	}
+
+	data, err := c.fetch(method, href, body)
+	if err != nil {
+		return err
+	}
+
+	if v == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// fetch sends a request to the Broker and reads the body of its response,
+// retrying idempotent requests that fail with a connection error or a 5xx.
+func (c *Client) fetch(method string, href string, body []byte) ([]byte, error) {
+	backoff := c.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		data, err := c.fetchOnce(method, href, body)
+		if err == nil || attempt >= c.MaxRetries || !retryable(method, err) || c.Context().Err() != nil {
+			return data, err
+		}
+
+		log.Printf("[DEBUG] pact broker: retrying %s %s in %v: %v", method, href, backoff, err)
+		select {
+		case <-c.Context().Done():
+			return nil, c.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// fetchOnce sends a request to the Broker and reads the body of its response.
+func (c *Client) fetchOnce(method string, href string, body []byte) ([]byte, error) {
+	ctx := c.Context()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(method, href, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Accept", "application/hal+json, application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.Auth != nil {
+		c.Auth.Authenticate(req)
+	}
+
+	log.Printf("[DEBUG] pact broker: %s %s", method, href)
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] pact broker response Body: %s\n", data)
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case res.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case res.StatusCode < 200 || res.StatusCode >= 300:
+		return nil, &Error{StatusCode: res.StatusCode, Body: string(data)}
+	}
+
+	return data, nil
+}
+
+// Digest returns the SHA-256 digest of the body of a request, to identify
+// its payload e.g. in a dry run.
+func Digest(body []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+}
+
+// retryable reports whether a failed request can be sent again. Requests that
+// are not idempotent, such as publishing verification results, are never
+// retried as they may have reached the Broker.
+func retryable(method string, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+
+	switch e := err.(type) {
+	case *url.Error:
+		// The request could not be sent, unless its URL is invalid
+		return e.Op != "parse"
+	case *Error:
+		return e.StatusCode >= 500
+	}
+
+	return false
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
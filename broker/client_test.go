@@ -0,0 +1,399 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// brokerRequest is a request received by the fake Broker.
+type brokerRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+	Auth   string
+}
+
+// setupBroker starts a fake Pact Broker with a HAL index. Each path is served
+// with the given response body, with "{url}" replaced by the Broker's URL.
+func setupBroker(responses map[string]string) (*httptest.Server, *[]brokerRequest) {
+	var requests []brokerRequest
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, brokerRequest{
+			Method: r.Method,
+			Path:   r.URL.EscapedPath(),
+			Query:  r.URL.RawQuery,
+			Body:   string(body),
+			Auth:   r.Header.Get("Authorization"),
+		})
+
+		response, ok := responses[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprint(w, strings.Replace(response, "{url}", server.URL, -1))
+	}))
+
+	return server, &requests
+}
+
+// halIndex is the index of the fake Broker, with non-standard paths to show
+// that they are followed.
+var halIndex = `{"_links":{
+	"pb:publish-pact":{"href":"{url}/hal/pacts/provider/{provider}/consumer/{consumer}/version/{consumerApplicationVersion}","templated":true},
+	"pb:latest-provider-pacts":{"href":"{url}/hal/pacts/provider/{provider}/latest","templated":true},
+	"pb:latest-provider-pacts-with-tag":{"href":"{url}/hal/pacts/provider/{provider}/latest/{tag}","templated":true},
+	"pb:pacticipants":{"href":"{url}/hal/pacticipants"},
+	"pb:pacticipant":{"href":"{url}/hal/pacticipants/{pacticipant}","templated":true},
+	"pb:pacticipant-version":{"href":"{url}/hal/pacticipants/{pacticipant}/versions/{version}","templated":true},
+	"pb:pacticipant-version-tag":{"href":"{url}/hal/pacticipants/{pacticipant}/versions/{version}/tags/{tag}","templated":true},
+	"curies":[{"name":"pb","href":"{url}/doc/{rel}","templated":true}]
+}}`
+
+func TestClient_Link(t *testing.T) {
+	server, requests := setupBroker(map[string]string{"/": halIndex})
+	defer server.Close()
+
+	client := &Client{BrokerURL: server.URL}
+	href, err := client.Link("pb:pacticipant", map[string]string{"pacticipant": "billy bob"})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if href != server.URL+"/hal/pacticipants/billy%20bob" {
+		t.Fatalf("expected the link from the index, got %s", href)
+	}
+
+	// The index is only fetched once
+	client.Link("pb:pacticipants", nil)
+	if len(*requests) != 1 {
+		t.Fatalf("expected the index to be cached, got %d requests", len(*requests))
+	}
+}
+
+func TestClient_LinkStandardPaths(t *testing.T) {
+	for _, index := range []string{"", "Hello, client", `{"_links":{}}`} {
+		responses := map[string]string{}
+		if index != "" {
+			responses["/"] = index
+		}
+		server, _ := setupBroker(responses)
+
+		client := &Client{BrokerURL: server.URL}
+		href, err := client.Link("pb:pacticipant-version", map[string]string{"pacticipant": "billy", "version": "1.0.0"})
+		server.Close()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+
+		if href != server.URL+"/pacticipants/billy/versions/1.0.0" {
+			t.Fatalf("expected the standard path for index %q, got %s", index, href)
+		}
+	}
+}
+
+func TestClient_IndexFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "something went wrong", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	_, err := client.Link("pb:pacticipants", nil)
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a broker error, got %v", err)
+	}
+}
+
+func TestClient_IndexConcurrent(t *testing.T) {
+	server, _ := setupBroker(map[string]string{"/": halIndex})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	var wg sync.WaitGroup
+	indexes := make([]*Resource, 5)
+	for i := range indexes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			indexes[i], _ = client.Index()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, index := range indexes {
+		if index == nil || index != indexes[0] {
+			t.Fatalf("expected every goroutine to get the cached index, got %v", indexes)
+		}
+	}
+}
+
+func TestClient_IndexPerClient(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer slow.Close()
+	defer close(release)
+	server, _ := setupBroker(map[string]string{"/": halIndex})
+	defer server.Close()
+
+	client := &Client{BrokerURL: slow.URL}
+	copied := client.WithContext(context.Background())
+	if copied.indexMu != client.indexMu {
+		t.Fatalf("expected WithContext to share the index lock of the client")
+	}
+	go client.Index()
+
+	done := make(chan error)
+	go func() {
+		_, err := (&Client{BrokerURL: server.URL}).Index()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the index of another client not to wait on a slow Broker")
+	}
+}
+
+func TestClient_LinkFail(t *testing.T) {
+	server, _ := setupBroker(map[string]string{"/": halIndex})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	if _, err := client.Link("pb:unknown", nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if _, err := client.Link("pb:pacticipant", nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if _, err := (&Client{}).Link("pb:pacticipant", nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if _, err := (&Client{BrokerURL: "%%%"}).Link("pb:pacticipant", nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	server, requests := setupBroker(map[string]string{"/": halIndex})
+	defer server.Close()
+
+	client := &Client{
+		BrokerURL: server.URL,
+		Auth:      BasicAuth{Username: "foo", Password: "bar"},
+	}
+	if _, err := client.Index(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if (*requests)[0].Auth != "Basic Zm9vOmJhcg==" {
+		t.Fatalf("expected basic auth, got %s", (*requests)[0].Auth)
+	}
+
+	client = &Client{
+		BrokerURL: server.URL,
+		Auth: AuthenticatorFunc(func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer 1234")
+		}),
+	}
+	client.Index()
+	if (*requests)[1].Auth != "Bearer 1234" {
+		t.Fatalf("expected custom auth, got %s", (*requests)[1].Auth)
+	}
+
+	client = &Client{
+		BrokerURL: server.URL,
+		Auth:      BearerAuth{Token: "abcd"},
+	}
+	client.Index()
+	if (*requests)[2].Auth != "Bearer abcd" {
+		t.Fatalf("expected bearer auth, got %s", (*requests)[2].Auth)
+	}
+}
+
+func TestClient_SendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/401":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/403":
+			w.WriteHeader(http.StatusForbidden)
+		case "/500":
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+		case "/empty":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	if err := client.Send(http.MethodGet, server.URL+"/401", nil, nil); err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if err := client.Send(http.MethodGet, server.URL+"/403", nil, nil); err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if err := client.Send(http.MethodGet, server.URL+"/404", nil, nil); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	err := client.Send(http.MethodGet, server.URL+"/500", nil, nil)
+	if e, ok := err.(*Error); !ok || e.StatusCode != 500 || strings.TrimSpace(e.Error()) != "something went wrong" {
+		t.Fatalf("expected a broker error, got %v", err)
+	}
+
+	err = client.Send(http.MethodGet, server.URL+"/empty", nil, nil)
+	if err == nil || err.Error() != "unexpected response from pact broker: 502 Bad Gateway" {
+		t.Fatalf("expected a broker error, got %v", err)
+	}
+
+	if _, err = (&Client{BrokerURL: server.URL + "/401"}).Index(); err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestClient_SendDryRun(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/pacts/1": `{}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL, DryRun: true}
+
+	if err := client.Send(http.MethodPut, server.URL+"/pacts/1", []byte(`{}`), nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(*requests) != 0 {
+		t.Fatalf("expected nothing to be sent in a dry run, got %+v", *requests)
+	}
+
+	if err := client.Send(http.MethodGet, server.URL+"/pacts/1", nil, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("expected GET requests to be sent in a dry run, got %+v", *requests)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	digest := Digest([]byte("foo"))
+	if digest != "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae" {
+		t.Fatalf("unexpected digest %s", digest)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var requests []string
+	failures := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case failures > 0 || r.Method == http.MethodPost:
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL, MaxRetries: 2, RetryBackoff: time.Millisecond}
+
+	if err := client.Send(http.MethodPut, server.URL+"/pacts/1", []byte(`{}`), nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected the request to be retried twice, got %v", requests)
+	}
+
+	// Requests that are not idempotent, or fail with a client error, are not retried
+	requests = nil
+	if err := client.Send(http.MethodPost, server.URL+"/pacts/1/verification-results", []byte(`{}`), nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if err := client.Send(http.MethodGet, server.URL+"/missing", nil, nil); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected no retries, got %v", requests)
+	}
+
+	// Retries are limited
+	failures = 5
+	requests = nil
+	if err := client.Send(http.MethodGet, server.URL+"/pacts/1", nil, nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 attempts, got %v", requests)
+	}
+}
+
+func TestClient_RetriesConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	brokerURL := server.URL
+	server.Close()
+
+	start := time.Now()
+	client := &Client{BrokerURL: brokerURL, MaxRetries: 2, RetryBackoff: 10 * time.Millisecond}
+	if err := client.Send(http.MethodGet, brokerURL, nil, nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected to back off between retries, took %v", elapsed)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL, Timeout: 10 * time.Millisecond}
+
+	if err := client.Send(http.MethodGet, server.URL, nil, nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestClient_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := (&Client{BrokerURL: server.URL, MaxRetries: 10, RetryBackoff: time.Hour}).WithContext(ctx)
+
+	if client.Context() != ctx {
+		t.Fatalf("expected the client to have the context")
+	}
+
+	start := time.Now()
+	if _, err := client.Index(); err != context.DeadlineExceeded {
+		t.Fatalf("expected the context deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the retries to be cancelled, took %v", elapsed)
+	}
+}
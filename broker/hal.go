@@ -0,0 +1,94 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// defaultLinks are the standard paths of the Broker, used for relations that
+// it does not advertise in its index.
+var defaultLinks = map[string]string{
+	"pb:latest-provider-pacts":           "/pacts/provider/{provider}/latest",
+	"pb:latest-provider-pacts-with-tag":  "/pacts/provider/{provider}/latest/{tag}",
+	"pb:provider-pacts-for-verification": "/pacts/provider/{provider}/for-verification",
+	"pb:publish-pact":                    "/pacts/provider/{provider}/consumer/{consumer}/version/{consumerApplicationVersion}",
+	"pb:pacticipants":                    "/pacticipants",
+	"pb:pacticipant":                     "/pacticipants/{pacticipant}",
+	"pb:pacticipant-version":             "/pacticipants/{pacticipant}/versions/{version}",
+	"pb:pacticipant-version-tag":         "/pacticipants/{pacticipant}/versions/{version}/tags/{tag}",
+	"pb:matrix":                          "/matrix",
+}
+
+// templateParam matches a parameter in a templated link e.g. {provider}.
+var templateParam = regexp.MustCompile(`{([^}]+)}`)
+
+// Link is a link to another resource in a HAL document.
+type Link struct {
+	Href      string `json:"href"`
+	Title     string `json:"title,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+// Expand replaces the parameters of a templated link with the given values.
+func (l Link) Expand(params map[string]string) (string, error) {
+	if !l.Templated {
+		return l.Href, nil
+	}
+
+	var err error
+	href := templateParam.ReplaceAllStringFunc(l.Href, func(param string) string {
+		name := param[1 : len(param)-1]
+		value, ok := params[name]
+		if !ok {
+			err = fmt.Errorf("missing parameter '%s' for link %s", name, l.Href)
+			return param
+		}
+		return url.PathEscape(value)
+	})
+
+	return href, err
+}
+
+// Links are the links of a HAL document by relation. A relation may have a
+// single link or an array of them.
+type Links map[string][]Link
+
+// Find returns the first link of a relation.
+func (l Links) Find(rel string) (Link, bool) {
+	if len(l[rel]) == 0 {
+		return Link{}, false
+	}
+	return l[rel][0], true
+}
+
+// UnmarshalJSON reads both single links and arrays of links.
+func (l *Links) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*l = make(Links, len(raw))
+	for rel, value := range raw {
+		var links []Link
+		if err := json.Unmarshal(value, &links); err != nil {
+			var link Link
+			if err = json.Unmarshal(value, &link); err != nil {
+				return fmt.Errorf("invalid link '%s': %v", rel, err)
+			}
+			links = []Link{link}
+		}
+		(*l)[rel] = links
+	}
+
+	return nil
+}
+
+// Resource is a HAL document returned by the Pact Broker.
+type Resource struct {
+	Links    Links                      `json:"_links,omitempty"`
+	Embedded map[string]json.RawMessage `json:"_embedded,omitempty"`
+}
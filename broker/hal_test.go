@@ -0,0 +1,52 @@
+package broker
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLinks_UnmarshalJSON(t *testing.T) {
+	var doc Resource
+	err := json.Unmarshal([]byte(`{"_links":{
+		"self":{"href":"http://broker/pacts"},
+		"pb:pacts":[{"href":"http://broker/pacts/1","name":"billy"},{"href":"http://broker/pacts/2","name":"sally"}]
+	}}`), &doc)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if self, ok := doc.Links.Find("self"); !ok || self.Href != "http://broker/pacts" {
+		t.Fatalf("expected a single link, got %v", doc.Links["self"])
+	}
+	if len(doc.Links["pb:pacts"]) != 2 || doc.Links["pb:pacts"][1].Name != "sally" {
+		t.Fatalf("expected an array of links, got %v", doc.Links["pb:pacts"])
+	}
+	if _, ok := doc.Links.Find("pb:missing"); ok {
+		t.Fatalf("expected no link for a missing relation")
+	}
+
+	if err = json.Unmarshal([]byte(`{"_links":{"self":"http://broker"}}`), &doc); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestLink_Expand(t *testing.T) {
+	link := Link{Href: "http://broker/pacts/provider/{provider}/latest/{tag}", Templated: true}
+
+	href, err := link.Expand(map[string]string{"provider": "bobby", "tag": "prod/eu"})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if href != "http://broker/pacts/provider/bobby/latest/prod%2Feu" {
+		t.Fatalf("expected an expanded link, got %s", href)
+	}
+
+	if _, err = link.Expand(map[string]string{"provider": "bobby"}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	link = Link{Href: "http://broker/{notatemplate}"}
+	if href, _ = link.Expand(nil); href != link.Href {
+		t.Fatalf("expected the link to be unchanged, got %s", href)
+	}
+}
@@ -0,0 +1,133 @@
+package broker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Selector selects the versions of a Pacticipant to query the matrix for.
+type Selector struct {
+	// Pacticipant is the name of the application.
+	Pacticipant string
+
+	// Version of the Pacticipant. Optional.
+	Version string
+
+	// Latest selects the latest version of the Pacticipant, or the latest
+	// with the Tag if one is given.
+	Latest bool
+
+	// Tag of the versions to select. Optional.
+	Tag string
+}
+
+// MatrixQuery is a query of the verification results between Pacticipants.
+type MatrixQuery struct {
+	// Selectors for the Pacticipant versions to query.
+	Selectors []Selector
+
+	// LatestBy limits the results to the latest per consumer version and
+	// provider ("cvp") or per consumer and provider version ("cvpv").
+	// Optional.
+	LatestBy string
+
+	// Latest, with a Tag, queries the selected versions against the latest
+	// versions of the other Pacticipants with the Tag e.g. "prod".
+	Latest bool
+
+	// Tag of the other Pacticipants to query against. Optional.
+	Tag string
+}
+
+// Encode encodes the query for the matrix resource. Each selector's fields
+// must be kept together, so the order of the parameters is preserved.
+func (q MatrixQuery) Encode() string {
+	var params []string
+	add := func(key string, value string) {
+		params = append(params, fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(value)))
+	}
+
+	for _, selector := range q.Selectors {
+		add("q[][pacticipant]", selector.Pacticipant)
+		if selector.Version != "" {
+			add("q[][version]", selector.Version)
+		}
+		if selector.Latest {
+			add("q[][latest]", "true")
+		}
+		if selector.Tag != "" {
+			add("q[][tag]", selector.Tag)
+		}
+	}
+
+	if q.LatestBy != "" {
+		add("latestby", q.LatestBy)
+	}
+	if q.Latest {
+		add("latest", "true")
+	}
+	if q.Tag != "" {
+		add("tag", q.Tag)
+	}
+
+	return strings.Join(params, "&")
+}
+
+// MatrixVersion is a version of a Pacticipant in the matrix.
+type MatrixVersion struct {
+	Number string `json:"number"`
+}
+
+// MatrixPacticipant is a Pacticipant in a row of the matrix.
+type MatrixPacticipant struct {
+	Name    string        `json:"name"`
+	Version MatrixVersion `json:"version"`
+}
+
+// MatrixVerification is the verification result of a row of the matrix.
+type MatrixVerification struct {
+	Success    bool   `json:"success"`
+	VerifiedAt string `json:"verifiedAt,omitempty"`
+}
+
+// MatrixRow is the verification of a Consumer version's Pact against a
+// Provider version. The VerificationResult is nil if the Pact has not been
+// verified.
+type MatrixRow struct {
+	Consumer           MatrixPacticipant   `json:"consumer"`
+	Provider           MatrixPacticipant   `json:"provider"`
+	VerificationResult *MatrixVerification `json:"verificationResult"`
+}
+
+// MatrixSummary summarises the matrix, including whether the selected
+// versions can be deployed together.
+type MatrixSummary struct {
+	Deployable *bool  `json:"deployable"`
+	Reason     string `json:"reason"`
+	Success    int    `json:"success"`
+	Failed     int    `json:"failed"`
+	Unknown    int    `json:"unknown"`
+}
+
+// Matrix is the result of a query of the matrix.
+type Matrix struct {
+	Summary MatrixSummary `json:"summary"`
+	Matrix  []MatrixRow   `json:"matrix"`
+}
+
+// Matrix queries the verification results between Pacticipant versions.
+func (c *Client) Matrix(query MatrixQuery) (*Matrix, error) {
+	href, err := c.Link("pb:matrix", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var matrix Matrix
+	if err = c.Send(http.MethodGet, fmt.Sprintf("%s?%s", href, query.Encode()), nil, &matrix); err != nil {
+		return nil, err
+	}
+
+	return &matrix, nil
+}
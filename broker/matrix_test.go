@@ -0,0 +1,56 @@
+package broker
+
+import (
+	"testing"
+)
+
+func TestMatrixQuery_Encode(t *testing.T) {
+	query := MatrixQuery{
+		Selectors: []Selector{
+			{Pacticipant: "billy", Version: "1.0.0"},
+			{Pacticipant: "bobby", Latest: true, Tag: "prod"},
+		},
+		LatestBy: "cvp",
+		Latest:   true,
+		Tag:      "prod",
+	}
+
+	expected := "q%5B%5D%5Bpacticipant%5D=billy&q%5B%5D%5Bversion%5D=1.0.0&" +
+		"q%5B%5D%5Bpacticipant%5D=bobby&q%5B%5D%5Blatest%5D=true&q%5B%5D%5Btag%5D=prod&" +
+		"latestby=cvp&latest=true&tag=prod"
+	if query.Encode() != expected {
+		t.Fatalf("expected %s, got %s", expected, query.Encode())
+	}
+}
+
+func TestClient_Matrix(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/matrix": `{
+			"summary":{"deployable":false,"reason":"One or more verifications failed","success":1,"failed":1,"unknown":0},
+			"matrix":[
+				{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":{"success":true}},
+				{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.1"}},"verificationResult":null}
+			]
+		}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	matrix, err := client.Matrix(MatrixQuery{
+		Selectors: []Selector{{Pacticipant: "billy", Version: "1.0.0"}},
+		LatestBy:  "cvp",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if matrix.Summary.Deployable == nil || *matrix.Summary.Deployable || matrix.Summary.Failed != 1 {
+		t.Fatalf("expected an undeployable summary, got %+v", matrix.Summary)
+	}
+	if len(matrix.Matrix) != 2 || matrix.Matrix[0].Provider.Version.Number != "2.0.0" || matrix.Matrix[1].VerificationResult != nil {
+		t.Fatalf("expected 2 rows, got %+v", matrix.Matrix)
+	}
+	if (*requests)[1].Query != "q%5B%5D%5Bpacticipant%5D=billy&q%5B%5D%5Bversion%5D=1.0.0&latestby=cvp" {
+		t.Fatalf("expected the query to be sent, got %s", (*requests)[1].Query)
+	}
+}
@@ -0,0 +1,115 @@
+package broker
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Pacticipant is an application that takes part in a Pact, as either a
+// Consumer or a Provider.
+type Pacticipant struct {
+	Name          string `json:"name"`
+	RepositoryURL string `json:"repositoryUrl,omitempty"`
+	CreatedAt     string `json:"createdAt,omitempty"`
+	UpdatedAt     string `json:"updatedAt,omitempty"`
+	Links         Links  `json:"_links,omitempty"`
+}
+
+// Version is a version of a Pacticipant.
+type Version struct {
+	Number    string `json:"number"`
+	Branch    string `json:"branch,omitempty"`
+	BuildURL  string `json:"buildUrl,omitempty"`
+	Tags      []Tag  `json:"-"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	Links     Links  `json:"_links,omitempty"`
+}
+
+// Tag is a label on a Version e.g. "prod".
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Pacticipant fetches a Pacticipant by name.
+func (c *Client) Pacticipant(name string) (*Pacticipant, error) {
+	var pacticipant Pacticipant
+	if err := c.Get("pb:pacticipant", map[string]string{"pacticipant": name}, &pacticipant); err != nil {
+		return nil, err
+	}
+
+	return &pacticipant, nil
+}
+
+// Pacticipants fetches all of the Pacticipants in the Broker.
+func (c *Client) Pacticipants() ([]Pacticipant, error) {
+	var doc struct {
+		Embedded struct {
+			Pacticipants []Pacticipant `json:"pacticipants"`
+		} `json:"_embedded"`
+	}
+	if err := c.Get("pb:pacticipants", nil, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc.Embedded.Pacticipants, nil
+}
+
+// Version fetches a version of a Pacticipant, along with its tags.
+func (c *Client) Version(pacticipant string, version string) (*Version, error) {
+	var doc struct {
+		Version
+		Embedded struct {
+			Tags []Tag `json:"tags"`
+		} `json:"_embedded"`
+	}
+	err := c.Get("pb:pacticipant-version", map[string]string{"pacticipant": pacticipant, "version": version}, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Version.Tags = doc.Embedded.Tags
+	return &doc.Version, nil
+}
+
+// TagVersion adds a tag to a version of a Pacticipant.
+func (c *Client) TagVersion(pacticipant string, version string, tag string) error {
+	href, err := c.Link("pb:pacticipant-version-tag", map[string]string{
+		"pacticipant": pacticipant,
+		"version":     version,
+		"tag":         tag,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Send(http.MethodPut, href, nil, nil)
+}
+
+// VersionProperties are the details of a version of a Pacticipant, such as
+// the branch it was built from.
+type VersionProperties struct {
+	// Branch the version was built from. Optional.
+	Branch string `json:"branch,omitempty"`
+
+	// BuildURL links to the build that produced the version. Optional.
+	BuildURL string `json:"buildUrl,omitempty"`
+}
+
+// UpdateVersion sets the properties of a version of a Pacticipant, creating
+// the version if it does not exist.
+func (c *Client) UpdateVersion(pacticipant string, version string, properties VersionProperties) error {
+	href, err := c.Link("pb:pacticipant-version", map[string]string{
+		"pacticipant": pacticipant,
+		"version":     version,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(http.MethodPut, href, data, nil)
+}
@@ -0,0 +1,100 @@
+package broker
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClient_Pacticipants(t *testing.T) {
+	server, _ := setupBroker(map[string]string{
+		"/":                       halIndex,
+		"/hal/pacticipants":       `{"_embedded":{"pacticipants":[{"name":"billy"},{"name":"bobby"}]}}`,
+		"/hal/pacticipants/billy": `{"name":"billy","repositoryUrl":"https://github.com/example/billy","_links":{"self":{"href":"{url}/hal/pacticipants/billy"}}}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	pacticipants, err := client.Pacticipants()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(pacticipants) != 2 || pacticipants[1].Name != "bobby" {
+		t.Fatalf("expected 2 pacticipants, got %v", pacticipants)
+	}
+
+	pacticipant, err := client.Pacticipant("billy")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if pacticipant.Name != "billy" || pacticipant.RepositoryURL != "https://github.com/example/billy" {
+		t.Fatalf("expected billy, got %v", pacticipant)
+	}
+
+	if _, err = client.Pacticipant("sally"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Version(t *testing.T) {
+	server, _ := setupBroker(map[string]string{
+		"/":                                      halIndex,
+		"/hal/pacticipants/billy/versions/1.0.0": `{"number":"1.0.0","_embedded":{"tags":[{"name":"dev"},{"name":"prod"}]}}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	version, err := client.Version("billy", "1.0.0")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if version.Number != "1.0.0" || len(version.Tags) != 2 || version.Tags[1].Name != "prod" {
+		t.Fatalf("expected version 1.0.0 with its tags, got %+v", version)
+	}
+
+	if _, err = client.Version("billy", "2.0.0"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_TagVersion(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/": halIndex,
+		"/hal/pacticipants/billy/versions/1.0.0/tags/prod": `{"name":"prod"}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	if err := client.TagVersion("billy", "1.0.0", "prod"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if (*requests)[1].Method != http.MethodPut {
+		t.Fatalf("expected the version to be tagged with a PUT, got %+v", (*requests)[1])
+	}
+
+	if err := client.TagVersion("billy", "2.0.0", "prod"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestClient_UpdateVersion(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/":                                      halIndex,
+		"/hal/pacticipants/billy/versions/1.0.0": `{"number":"1.0.0","branch":"main"}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	err := client.UpdateVersion("billy", "1.0.0", VersionProperties{Branch: "main", BuildURL: "http://ci/1"})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	req := (*requests)[1]
+	if req.Method != http.MethodPut || req.Body != `{"branch":"main","buildUrl":"http://ci/1"}` {
+		t.Fatalf("expected the version to be updated with a PUT, got %+v", req)
+	}
+
+	if err = client.UpdateVersion("billy", "2.0.0", VersionProperties{Branch: "main"}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -0,0 +1,144 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// PublishPact publishes the contents of a Pact file for a version of its
+// Consumer.
+func (c *Client) PublishPact(provider string, consumer string, consumerVersion string, pact []byte) error {
+	href, err := c.PactURL(provider, consumer, consumerVersion)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(http.MethodPut, href, pact, nil)
+}
+
+// PactURL returns the URL of the Pact for a version of a Consumer, which is
+// where it is published to.
+func (c *Client) PactURL(provider string, consumer string, consumerVersion string) (string, error) {
+	return c.Link("pb:publish-pact", map[string]string{
+		"provider":                   provider,
+		"consumer":                   consumer,
+		"consumerApplicationVersion": consumerVersion,
+	})
+}
+
+// LatestPacts returns links to the latest Pact of each Consumer of a
+// Provider. If a tag is given, only the latest Pacts with the tag are
+// returned.
+func (c *Client) LatestPacts(provider string, tag string) ([]Link, error) {
+	var doc Resource
+	var err error
+	if tag == "" {
+		err = c.Get("pb:latest-provider-pacts", map[string]string{"provider": provider}, &doc)
+	} else {
+		err = c.Get("pb:latest-provider-pacts-with-tag", map[string]string{"provider": provider, "tag": tag}, &doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Older Brokers only have the 'pacts' relation
+	// See https://github.com/pact-foundation/pact_broker/issues/209#issuecomment-390437990
+	links := append([]Link{}, doc.Links["pb:pacts"]...)
+	return append(links, doc.Links["pacts"]...), nil
+}
+
+// Pact fetches a Pact from the Broker. The Resource has the links of the
+// Pact, such as where to publish verification results, and the contents are
+// the Pact file itself.
+func (c *Client) Pact(pactURL string) (*Resource, []byte, error) {
+	data, err := c.fetch(http.MethodGet, pactURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc Resource
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse pact %s: %v", pactURL, err)
+	}
+
+	return &doc, data, nil
+}
+
+// Notice is a message from the Broker about a Pact for verification, such as
+// why it was selected.
+type Notice struct {
+	// When the notice is relevant e.g. "before_verification".
+	When string `json:"when"`
+
+	// Text of the notice.
+	Text string `json:"text"`
+}
+
+// VerificationProperties are the properties of a Pact for its verification.
+type VerificationProperties struct {
+	// Pending is true if the Provider has never successfully verified the
+	// Pact, so its failure should not fail the verification.
+	Pending bool `json:"pending,omitempty"`
+
+	// WIP is true for a work in progress Pact, which is also pending.
+	WIP bool `json:"wip,omitempty"`
+
+	Notices []Notice `json:"notices,omitempty"`
+}
+
+// PactForVerification is a Pact selected by the Broker for verification.
+type PactForVerification struct {
+	ShortDescription       string                 `json:"shortDescription"`
+	VerificationProperties VerificationProperties `json:"verificationProperties"`
+	Links                  Links                  `json:"_links"`
+}
+
+// URL of the Pact.
+func (p PactForVerification) URL() string {
+	link, _ := p.Links.Find("self")
+	return link.Href
+}
+
+// PactsForVerificationRequest selects the Pacts for a Provider to verify.
+type PactsForVerificationRequest struct {
+	ConsumerVersionSelectors []types.ConsumerVersionSelector `json:"consumerVersionSelectors,omitempty"`
+
+	// ProviderVersionTags of the Provider version being verified, which
+	// decide whether a Pact is pending.
+	ProviderVersionTags []string `json:"providerVersionTags,omitempty"`
+
+	// IncludePendingStatus includes whether each Pact is pending.
+	IncludePendingStatus bool `json:"includePendingStatus,omitempty"`
+
+	// IncludeWIPPactsSince includes the work in progress Pacts published
+	// since the time e.g. "2020-01-01T00:00:00Z".
+	IncludeWIPPactsSince string `json:"includeWipPactsSince,omitempty"`
+}
+
+// PactsForVerification asks the Broker for the Pacts a Provider should
+// verify, based on the Consumer version selectors of the request.
+func (c *Client) PactsForVerification(provider string, request PactsForVerificationRequest) ([]PactForVerification, error) {
+	href, err := c.Link("pb:provider-pacts-for-verification", map[string]string{"provider": provider})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Embedded struct {
+			Pacts []PactForVerification `json:"pacts"`
+		} `json:"_embedded"`
+	}
+	if err = c.Send(http.MethodPost, href, data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc.Embedded.Pacts, nil
+}
@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestClient_PublishPact(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/": halIndex,
+		"/hal/pacts/provider/bobby/consumer/billy/version/1.0.0": "",
+	})
+	defer server.Close()
+
+	client := &Client{BrokerURL: server.URL}
+	err := client.PublishPact("bobby", "billy", "1.0.0", []byte(`{"consumer":{"name":"billy"}}`))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	req := (*requests)[1]
+	if req.Method != http.MethodPut || req.Body != `{"consumer":{"name":"billy"}}` {
+		t.Fatalf("expected the pact to be published, got %+v", req)
+	}
+}
+
+func TestClient_LatestPacts(t *testing.T) {
+	server, _ := setupBroker(map[string]string{
+		"/": halIndex,
+		"/hal/pacts/provider/bobby/latest": `{"_links":{
+			"pb:pacts":[{"href":"{url}/pacts/provider/bobby/consumer/billy/version/1.0.0","name":"billy"}],
+			"pacts":[{"href":"{url}/pacts/provider/bobby/consumer/jessica/version/2.0.0","name":"jessica"}]
+		}}`,
+		"/hal/pacts/provider/bobby/latest/prod": `{"_links":{
+			"pb:pacts":[{"href":"{url}/pacts/provider/bobby/consumer/billy/version/0.9.0","name":"billy"}]
+		}}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	links, err := client.LatestPacts("bobby", "")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(links) != 2 || links[0].Name != "billy" || links[1].Name != "jessica" {
+		t.Fatalf("expected the latest pacts, got %v", links)
+	}
+
+	links, err = client.LatestPacts("bobby", "prod")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(links) != 1 || links[0].Href != server.URL+"/pacts/provider/bobby/consumer/billy/version/0.9.0" {
+		t.Fatalf("expected the latest prod pacts, got %v", links)
+	}
+
+	if _, err = client.LatestPacts("idontexist", ""); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Pact(t *testing.T) {
+	server, _ := setupBroker(map[string]string{
+		"/pacts/1": `{"consumer":{"name":"billy"},"_links":{"pb:publish-verification-results":{"href":"{url}/results"}}}`,
+		"/broken":  `broken`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	doc, data, err := client.Pact(server.URL + "/pacts/1")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, ok := doc.Links.Find("pb:publish-verification-results"); !ok || len(data) == 0 {
+		t.Fatalf("expected the pact and its links, got %v", doc)
+	}
+
+	if _, _, err = client.Pact(server.URL + "/broken"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestClient_PactsForVerification(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/pacts/provider/bobby/for-verification": `{"_embedded":{"pacts":[{
+			"shortDescription":"latest with tag prod",
+			"verificationProperties":{"notices":[{"when":"before_verification","text":"The pact at ... is being verified because it matches the selector {tag: prod}"}]},
+			"_links":{"self":{"href":"{url}/pacts/1","name":"Pact between billy (1.0.0) and bobby"}}
+		}]}}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	pacts, err := client.PactsForVerification("bobby", PactsForVerificationRequest{
+		ConsumerVersionSelectors: []types.ConsumerVersionSelector{{Tag: "prod", Latest: true}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(pacts) != 1 || pacts[0].URL() != server.URL+"/pacts/1" || len(pacts[0].VerificationProperties.Notices) != 1 {
+		t.Fatalf("expected a pact for verification, got %+v", pacts)
+	}
+	req := (*requests)[1]
+	if req.Method != http.MethodPost || req.Body != `{"consumerVersionSelectors":[{"tag":"prod","latest":true}]}` {
+		t.Fatalf("expected the selectors to be posted, got %+v", req)
+	}
+}
@@ -0,0 +1,71 @@
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// NewHTTPClient creates an HTTP client that connects to a Pact Broker with
+// the certificates and proxy of the transport.
+func NewHTTPClient(transport types.BrokerTransport) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: transport.InsecureSkipVerify,
+	}
+
+	if transport.CACertFile != "" {
+		pem, err := ioutil.ReadFile(transport.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificates: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificates found in %s", transport.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if transport.ClientCertFile != "" || transport.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(transport.ClientCertFile, transport.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if transport.ProxyURL != "" {
+		proxyURL, err := url.Parse(transport.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	// The same settings as http.DefaultTransport
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSClientConfig:       tlsConfig,
+		},
+	}, nil
+}
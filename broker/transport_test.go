@@ -0,0 +1,101 @@
+package broker
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func writeCACert(t *testing.T, server *httptest.Server) string {
+	dir, err := ioutil.TempDir("", "pact-go")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	file := filepath.Join(dir, "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err = ioutil.WriteFile(file, data, 0644); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return file
+}
+
+func TestNewHTTPClient_CACertFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	file := writeCACert(t, server)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	// The Broker's certificate is not trusted by default
+	httpClient, err := NewHTTPClient(types.BrokerTransport{})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	client := &Client{BrokerURL: server.URL, HTTPClient: httpClient}
+	if err = client.Send(http.MethodGet, server.URL, nil, nil); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	httpClient, err = NewHTTPClient(types.BrokerTransport{CACertFile: file})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	client = &Client{BrokerURL: server.URL, HTTPClient: httpClient}
+	if err = client.Send(http.MethodGet, server.URL, nil, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	httpClient, err = NewHTTPClient(types.BrokerTransport{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	client = &Client{BrokerURL: server.URL, HTTPClient: httpClient}
+	if err = client.Send(http.MethodGet, server.URL, nil, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestNewHTTPClient_ProxyURL(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	httpClient, err := NewHTTPClient(types.BrokerTransport{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	client := &Client{BrokerURL: "http://broker.example.com", HTTPClient: httpClient}
+	if err = client.Send(http.MethodGet, "http://broker.example.com/pacts", nil, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if proxied != "http://broker.example.com/pacts" {
+		t.Fatalf("expected the request to go through the proxy, got %s", proxied)
+	}
+}
+
+func TestNewHTTPClient_Invalid(t *testing.T) {
+	transports := []types.BrokerTransport{
+		{CACertFile: "does-not-exist.pem"},
+		{ClientCertFile: "does-not-exist.pem", ClientKeyFile: "does-not-exist.key"},
+		{ProxyURL: "://invalid"},
+	}
+
+	for _, transport := range transports {
+		if _, err := NewHTTPClient(transport); err == nil {
+			t.Fatalf("Expected error but got none for %+v", transport)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// VerificationResult is the outcome of verifying a Pact against a version of
+// its Provider.
+type VerificationResult struct {
+	// Success is true if all interactions in the Pact were verified.
+	Success bool `json:"success"`
+
+	// ProviderApplicationVersion is the version of the Provider that was
+	// verified.
+	ProviderApplicationVersion string `json:"providerApplicationVersion"`
+
+	// BuildURL links to the build that ran the verification. Optional.
+	BuildURL string `json:"buildUrl,omitempty"`
+
+	// TestResults are the details of the verification, such as TestResults.
+	// Optional.
+	TestResults interface{} `json:"testResults,omitempty"`
+}
+
+// TestResults are the details of a verification, with the outcome of
+// verifying each interaction of the Pact.
+type TestResults struct {
+	Tests []TestResult `json:"tests"`
+}
+
+// TestResult is the outcome of verifying a single interaction.
+type TestResult struct {
+	TestDescription     string         `json:"testDescription"`
+	TestFullDescription string         `json:"testFullDescription"`
+	Status              string         `json:"status"`
+	Exception           *TestException `json:"exception,omitempty"`
+}
+
+// TestException describes why an interaction failed verification.
+type TestException struct {
+	Class   string `json:"class,omitempty"`
+	Message string `json:"message"`
+}
+
+// PublishVerificationResult publishes the result of verifying the Pact at the
+// given URL.
+func (c *Client) PublishVerificationResult(pactURL string, result VerificationResult) error {
+	pact, _, err := c.Pact(pactURL)
+	if err != nil {
+		return err
+	}
+
+	link, ok := pact.Links.Find("pb:publish-verification-results")
+	if !ok {
+		return errors.New("pact broker does not support publishing verification results for " + pactURL)
+	}
+
+	href, err := link.Expand(nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return c.Send(http.MethodPost, href, data, nil)
+}
@@ -0,0 +1,33 @@
+package broker
+
+import (
+	"testing"
+)
+
+func TestClient_PublishVerificationResult(t *testing.T) {
+	server, requests := setupBroker(map[string]string{
+		"/pacts/1":                      `{"_links":{"pb:publish-verification-results":{"href":"{url}/pacts/1/verification-results"}}}`,
+		"/pacts/1/verification-results": `{}`,
+		"/pacts/2":                      `{"_links":{}}`,
+	})
+	defer server.Close()
+	client := &Client{BrokerURL: server.URL}
+
+	err := client.PublishVerificationResult(server.URL+"/pacts/1", VerificationResult{
+		Success:                    true,
+		ProviderApplicationVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	req := (*requests)[1]
+	if req.Method != "POST" || req.Path != "/pacts/1/verification-results" ||
+		req.Body != `{"success":true,"providerApplicationVersion":"1.0.0"}` {
+		t.Fatalf("expected the result to be published, got %+v", req)
+	}
+
+	if err = client.PublishVerificationResult(server.URL+"/pacts/2", VerificationResult{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
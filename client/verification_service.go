@@ -19,6 +19,7 @@ type VerificationService struct {
 // 		--provider-states-setup-url
 // 		--broker-username
 // 		--broker-password
+// 		--broker-token
 //    --publish-verification-results
 //    --provider-app-version
 //    --custom-provider-headers
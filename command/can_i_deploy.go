@@ -0,0 +1,130 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var canIDeployPacticipants []string
+var canIDeployLatest string
+var canIDeployRequest = types.CanIDeployRequest{}
+var canIDeployOutput string
+var canIDeployRetryInterval int
+
+var canIDeployCmd = &cobra.Command{
+	Use:   "can-i-deploy",
+	Short: "Check if application versions are safe to deploy",
+	Long: `Checks with a Pact Broker whether the given application versions have been
+successfully verified against the versions of the applications they integrate
+with, exiting with a non-zero status if they are not safe to deploy.
+
+Each --pacticipant is given as name@version. Pacticipants without a version
+are checked at their latest version, or their latest with the --latest tag.`,
+	Example: `  pact-go can-i-deploy --broker-base-url http://broker --pacticipant billy@1.0.0 --pacticipant bobby --latest master`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		ok, err := canIDeploy(os.Stdout, canIDeployArgs(), canIDeployOutput)
+		if err != nil {
+			log.Println("[ERROR]", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+// canIDeployArgs builds the request from the command line flags.
+func canIDeployArgs() types.CanIDeployRequest {
+	request := canIDeployRequest
+	request.RetryInterval = time.Duration(canIDeployRetryInterval) * time.Second
+	request.Selectors = nil
+
+	for _, pacticipant := range canIDeployPacticipants {
+		selector := types.CanIDeploySelector{Pacticipant: pacticipant}
+		if i := strings.LastIndex(pacticipant, "@"); i > 0 {
+			selector.Pacticipant = pacticipant[:i]
+			selector.Version = pacticipant[i+1:]
+		} else {
+			selector.Latest = true
+			selector.Tag = canIDeployLatest
+		}
+		request.Selectors = append(request.Selectors, selector)
+	}
+
+	return request
+}
+
+// canIDeploy checks whether the requested versions can be deployed, writing
+// the result as a table or as JSON.
+func canIDeploy(out io.Writer, request types.CanIDeployRequest, output string) (bool, error) {
+	if output != "table" && output != "json" {
+		return false, fmt.Errorf("invalid output format '%s', must be one of table or json", output)
+	}
+
+	res, err := dsl.CanIDeploy(request)
+	if err != nil {
+		return false, err
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintln(out, string(data))
+		return res.Deployable, nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONSUMER\tC.VERSION\tPROVIDER\tP.VERSION\tSTATUS")
+	for _, result := range res.Matrix {
+		providerVersion := result.ProviderVersion
+		if providerVersion == "" {
+			providerVersion = "???"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Consumer, result.ConsumerVersion, result.Provider, providerVersion, result.Status)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	if res.Deployable {
+		fmt.Fprintln(out, "Computer says yes \\o/")
+	} else {
+		fmt.Fprintln(out, "Computer says no ¯\\_(ツ)_/¯")
+	}
+	if res.Reason != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, res.Reason)
+	}
+
+	return res.Deployable, nil
+}
+
+func init() {
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.BrokerURL, "broker-base-url", "b", "", "URL of the Pact Broker")
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.BrokerUsername, "broker-username", "u", "", "Username for Pact Broker basic authentication")
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.BrokerPassword, "broker-password", "p", "", "Password for Pact Broker basic authentication")
+	canIDeployCmd.Flags().StringVarP(&canIDeployRequest.BrokerToken, "broker-token", "k", "", "Token for Pact Broker bearer authentication")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.BrokerTransport.CACertFile, "ca-cert", "", "PEM bundle of CA certificates to trust when connecting to the Pact Broker")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.BrokerTransport.ClientCertFile, "client-cert", "", "PEM client certificate to authenticate to the Pact Broker with")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.BrokerTransport.ClientKeyFile, "client-key", "", "PEM private key of the client certificate")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.BrokerTransport.ProxyURL, "proxy", "", "Proxy to connect to the Pact Broker through")
+	canIDeployCmd.Flags().StringSliceVarP(&canIDeployPacticipants, "pacticipant", "a", nil, "Application to check as name@version, or name for its latest version (may be repeated)")
+	canIDeployCmd.Flags().StringVar(&canIDeployLatest, "latest", "", "Tag of the latest version to check, for applications without a version")
+	canIDeployCmd.Flags().StringVar(&canIDeployRequest.To, "to", "", "Tag of the environment to deploy to e.g. prod")
+	canIDeployCmd.Flags().StringVarP(&canIDeployOutput, "output", "o", "table", "Output format, one of table or json")
+	canIDeployCmd.Flags().IntVar(&canIDeployRequest.RetryWhileUnknown, "retry-while-unknown", 0, "Number of times to retry while verification results are unknown")
+	canIDeployCmd.Flags().IntVar(&canIDeployRetryInterval, "retry-interval", 10, "Seconds to wait between retries")
+	RootCmd.AddCommand(canIDeployCmd)
+}
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func setupMatrixBroker(deployable bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/matrix" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"summary":{"deployable":%t,"reason":"some reason","success":1,"failed":0,"unknown":0},"matrix":[
+			{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":{"success":%t}}
+		]}`, deployable, deployable)
+	}))
+}
+
+func TestCanIDeployCommand_Table(t *testing.T) {
+	broker := setupMatrixBroker(true)
+	defer broker.Close()
+
+	var out bytes.Buffer
+	ok, err := canIDeploy(&out, types.CanIDeployRequest{
+		BrokerURL: broker.URL,
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+	}, "table")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if !ok {
+		t.Fatalf("expected to be able to deploy")
+	}
+	for _, expected := range []string{"CONSUMER  C.VERSION  PROVIDER  P.VERSION  STATUS", "billy     1.0.0      bobby     2.0.0      success", "Computer says yes", "some reason"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but got '%s'", expected, out.String())
+		}
+	}
+}
+
+func TestCanIDeployCommand_JSON(t *testing.T) {
+	broker := setupMatrixBroker(false)
+	defer broker.Close()
+
+	var out bytes.Buffer
+	ok, err := canIDeploy(&out, types.CanIDeployRequest{
+		BrokerURL: broker.URL,
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+	}, "json")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	if ok {
+		t.Fatalf("expected not to be able to deploy")
+	}
+	if !strings.Contains(out.String(), `"deployable": false`) || !strings.Contains(out.String(), `"status": "failed"`) {
+		t.Fatalf("expected JSON output but got '%s'", out.String())
+	}
+}
+
+func TestCanIDeployCommand_Fail(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := canIDeploy(&out, types.CanIDeployRequest{}, "yaml"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if _, err := canIDeploy(&out, types.CanIDeployRequest{}, "table"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestCanIDeployCommand_Args(t *testing.T) {
+	canIDeployPacticipants = []string{"billy@1.0.0", "bobby"}
+	canIDeployLatest = "master"
+	defer func() {
+		canIDeployPacticipants = nil
+		canIDeployLatest = ""
+	}()
+
+	request := canIDeployArgs()
+	expected := []types.CanIDeploySelector{
+		{Pacticipant: "billy", Version: "1.0.0"},
+		{Pacticipant: "bobby", Latest: true, Tag: "master"},
+	}
+	if len(request.Selectors) != 2 || request.Selectors[0] != expected[0] || request.Selectors[1] != expected[1] {
+		t.Fatalf("expected selectors %v, got %v", expected, request.Selectors)
+	}
+}
+
+func TestCanIDeployCommand_Execute(t *testing.T) {
+	broker := setupMatrixBroker(true)
+	defer broker.Close()
+	defer func() {
+		RootCmd.SetArgs(nil)
+		canIDeployPacticipants = nil
+		canIDeployLatest = ""
+		canIDeployRequest = types.CanIDeployRequest{}
+	}()
+
+	RootCmd.SetArgs([]string{"can-i-deploy", "-l", "ERROR", "--broker-base-url", broker.URL, "--pacticipant", "billy@1.0.0", "--latest", "master"})
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if logLevel != "ERROR" || canIDeployLatest != "master" || len(canIDeployPacticipants) != 1 {
+		t.Fatalf("expected the flags to be parsed, got %s %s %v", logLevel, canIDeployLatest, canIDeployPacticipants)
+	}
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/utils"
+	"github.com/spf13/cobra"
+)
+
+var mockService = dsl.NativeMockService{}
+var mockHost string
+var mockPorts string
+
+var mockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Run a standalone mock provider",
+	Long: `Runs the native mock service as a mock provider until it is interrupted,
+so that consumer tests written with other tools can drive it over HTTP.
+
+Requests with the X-Pact-Mock-Service header use the administration API of
+the Pact Mock Service:
+
+  POST   /interactions               registers an expected interaction
+  DELETE /interactions               removes the expected interactions
+  GET    /interactions/verification  checks the interactions were all called
+  POST   /pact                       writes the pact to the pact directory
+
+All other requests are matched against the registered interactions.`,
+	Example: `  pact-go mock --consumer billy --provider bobby --port 8080-8090 --pact-dir ./pacts`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if err := startMock(os.Stdout, &mockService, mockHost, mockPorts); err != nil {
+			log.Println("[ERROR]", err)
+			os.Exit(1)
+		}
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+		mockService.Stop()
+	},
+}
+
+// startMock starts the mock service on the first free port of the allowed
+// ports, or any free port if none are given, writing its URL.
+func startMock(out io.Writer, service *dsl.NativeMockService, host string, ports string) error {
+	port, err := findPort(ports)
+	if err != nil {
+		return fmt.Errorf("unable to find a free port for the mock service: %v", err)
+	}
+
+	if err = service.Start("tcp", fmt.Sprintf("%s:%d", host, port)); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Mock service listening on", service.URL())
+
+	return nil
+}
+
+// findPort returns the first free port of the allowed ports, e.g. "8080,8081"
+// or "8080-8090", or any free port if none are given.
+func findPort(ports string) (int, error) {
+	if ports != "" {
+		return utils.FindPortInRange(ports)
+	}
+	return utils.GetFreePort()
+}
+
+func init() {
+	mockCmd.Flags().StringVar(&mockHost, "host", "localhost", "Host to listen on")
+	mockCmd.Flags().StringVar(&mockPorts, "port", "", `Port to listen on, or ports to choose a free one from e.g. "8080,8081" or "8080-8090"`)
+	mockCmd.Flags().StringVar(&mockService.Consumer, "consumer", "", "Name of the consumer, unless given when writing the pact")
+	mockCmd.Flags().StringVar(&mockService.Provider, "provider", "", "Name of the provider, unless given when writing the pact")
+	mockCmd.Flags().StringVar(&mockService.PactDir, "pact-dir", "pacts", "Directory to write pacts to")
+	mockCmd.Flags().StringVar(&mockService.PactFileWriteMode, "pact-file-write-mode", "overwrite", "How to write to the pact file, one of overwrite, merge, update or none")
+	mockCmd.Flags().IntVar(&mockService.SpecificationVersion, "pact-specification-version", 2, "Pact specification version of the pact file, 2 or 3")
+	RootCmd.AddCommand(mockCmd)
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/utils"
+)
+
+func TestMockCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pact-go")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	service := &dsl.NativeMockService{PactDir: dir}
+	if err = startMock(&out, service, "localhost", ""); err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer service.Stop()
+
+	if !strings.Contains(out.String(), service.URL()) {
+		t.Fatalf("expected output to contain the URL but got '%s'", out.String())
+	}
+
+	// Drive the mock service through its administration API
+	client := &dsl.MockService{
+		Native:   true,
+		BaseURL:  service.URL(),
+		Consumer: "billy",
+		Provider: "bobby",
+	}
+	interaction := (&dsl.Interaction{}).
+		UponReceiving("A request for user 1").
+		WithRequest(dsl.Request{Method: "GET", Path: dsl.String("/users/1")}).
+		WillRespondWith(dsl.Response{Status: 200})
+	if err = client.AddInteraction(interaction); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = client.Verify(); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	res, err := http.Get(service.URL() + "/users/1")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+	if err = client.Verify(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = client.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "billy-bobby.json")); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestMockCommand_Ports(t *testing.T) {
+	port, err := utils.GetFreePort()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	var out bytes.Buffer
+	service := &dsl.NativeMockService{}
+	if err = startMock(&out, service, "localhost", "notaport,8080"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if err = startMock(&out, service, "localhost", fmt.Sprintf("%d-%d", port, port)); err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer service.Stop()
+	if !strings.HasSuffix(service.URL(), fmt.Sprintf(":%d", port)) {
+		t.Fatalf("expected the mock service to listen on port %d, got %s", port, service.URL())
+	}
+}
+
+func TestMockCommand_WriteMode(t *testing.T) {
+	var out bytes.Buffer
+	service := &dsl.NativeMockService{PactFileWriteMode: "append"}
+	if err := startMock(&out, service, "localhost", ""); err == nil {
+		service.Stop()
+		t.Fatalf("Expected error but got none")
+	}
+}
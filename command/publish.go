@@ -0,0 +1,124 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var publishRequest = types.PublishRequest{}
+var publishOutput string
+
+// publishEnv are the environment variables read for the flags that are not
+// set, and the fields of the request they set.
+var publishEnv = map[string]func(*types.PublishRequest) *string{
+	"PACT_BROKER_BASE_URL":  func(r *types.PublishRequest) *string { return &r.PactBroker },
+	"PACT_BROKER_USERNAME":  func(r *types.PublishRequest) *string { return &r.BrokerUsername },
+	"PACT_BROKER_PASSWORD":  func(r *types.PublishRequest) *string { return &r.BrokerPassword },
+	"PACT_BROKER_TOKEN":     func(r *types.PublishRequest) *string { return &r.BrokerToken },
+	"PACT_CONSUMER_VERSION": func(r *types.PublishRequest) *string { return &r.ConsumerVersion },
+	"PACT_CONSUMER_BRANCH":  func(r *types.PublishRequest) *string { return &r.Branch },
+	"PACT_BUILD_URL":        func(r *types.PublishRequest) *string { return &r.BuildURL },
+}
+
+var publishCmd = &cobra.Command{
+	Use:   "publish PACT_FILES_OR_DIRS...",
+	Short: "Publish pacts to a Pact Broker",
+	Long: `Publishes pact files to a Pact Broker, tagging the consumer version with the
+given tags and the current git branch. Directories are expanded to the *.json files they contain, and
+glob patterns such as "pacts/*-provider.json" are supported.
+
+Flags that are not set are read from the environment variables
+PACT_BROKER_BASE_URL, PACT_BROKER_USERNAME, PACT_BROKER_PASSWORD,
+PACT_BROKER_TOKEN, PACT_CONSUMER_VERSION, PACT_CONSUMER_BRANCH and
+PACT_BUILD_URL.`,
+	Example: `  pact-go publish ./pacts --broker-base-url http://broker --consumer-app-version 1.0.0 --tag master`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		err := publish(os.Stdout, publishArgs(args, os.Getenv), publishOutput)
+		if err != nil {
+			log.Println("[ERROR]", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// publishArgs builds the request from the command line arguments and flags,
+// falling back to the environment for flags that are not set.
+func publishArgs(args []string, getenv func(string) string) types.PublishRequest {
+	request := publishRequest
+	request.PactURLs = args
+
+	for name, field := range publishEnv {
+		if value := field(&request); *value == "" {
+			*value = getenv(name)
+		}
+	}
+
+	return request
+}
+
+// publish sends the pacts to the broker, writing the result of each pact as
+// text or as JSON. The result is written even if some pacts failed.
+func publish(out io.Writer, request types.PublishRequest, output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid output format '%s', must be one of text or json", output)
+	}
+
+	p := dsl.Publisher{}
+	res, err := p.PublishWithResult(request)
+
+	if output == "json" {
+		data, jsonErr := json.MarshalIndent(res, "", "  ")
+		if jsonErr != nil {
+			return jsonErr
+		}
+		fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	for _, pact := range res.Pacts {
+		if pact.Error != "" {
+			fmt.Fprintf(out, "Failed to publish %s: %s\n", pact.File, pact.Error)
+			continue
+		}
+
+		verb := "Published"
+		if res.DryRun {
+			verb = "Would publish"
+		}
+		fmt.Fprintf(out, "%s pact between %s and %s to %s\n", verb, pact.Consumer, pact.Provider, pact.URL)
+		if len(pact.Tags) > 0 {
+			fmt.Fprintf(out, "  tagged with %s\n", strings.Join(pact.Tags, ", "))
+		}
+	}
+
+	return err
+}
+
+func init() {
+	publishCmd.Flags().StringVarP(&publishRequest.PactBroker, "broker-base-url", "b", "", "URL of the Pact Broker")
+	publishCmd.Flags().StringVarP(&publishRequest.BrokerUsername, "broker-username", "u", "", "Username for Pact Broker basic authentication")
+	publishCmd.Flags().StringVarP(&publishRequest.BrokerPassword, "broker-password", "p", "", "Password for Pact Broker basic authentication")
+	publishCmd.Flags().StringVarP(&publishRequest.BrokerToken, "broker-token", "k", "", "Token for Pact Broker bearer authentication")
+	publishCmd.Flags().StringVar(&publishRequest.BrokerTransport.CACertFile, "ca-cert", "", "PEM bundle of CA certificates to trust when connecting to the Pact Broker")
+	publishCmd.Flags().StringVar(&publishRequest.BrokerTransport.ClientCertFile, "client-cert", "", "PEM client certificate to authenticate to the Pact Broker with")
+	publishCmd.Flags().StringVar(&publishRequest.BrokerTransport.ClientKeyFile, "client-key", "", "PEM private key of the client certificate")
+	publishCmd.Flags().StringVar(&publishRequest.BrokerTransport.ProxyURL, "proxy", "", "Proxy to connect to the Pact Broker through")
+	publishCmd.Flags().StringVarP(&publishRequest.ConsumerVersion, "consumer-app-version", "a", "", "Version of the consumer the pacts were built from")
+	publishCmd.Flags().StringSliceVarP(&publishRequest.Tags, "tag", "t", nil, "Tag to apply to the consumer version (may be repeated)")
+	publishCmd.Flags().BoolVar(&publishRequest.DisableGitBranchTag, "no-git-branch-tag", false, "Do not also tag the consumer version with the current git branch")
+	publishCmd.Flags().StringVar(&publishRequest.Branch, "branch", "", "Branch of the consumer the pacts were built from")
+	publishCmd.Flags().StringVar(&publishRequest.BuildURL, "build-url", "", "URL of the build that produced the pacts")
+	publishCmd.Flags().BoolVar(&publishRequest.DryRun, "dry-run", false, "Show what would be published without sending anything to the Pact Broker")
+	publishCmd.Flags().StringVarP(&publishOutput, "output", "o", "text", "Output format, one of text or json")
+	RootCmd.AddCommand(publishCmd)
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// setupPublishBroker pretends to be a Broker without a HAL index, recording
+// the pacts and tags published to it.
+func setupPublishBroker() (*httptest.Server, *[]string) {
+	var published []string
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		published = append(published, req.URL.EscapedPath())
+		w.Write([]byte(`{}`))
+	})), &published
+}
+
+func writePublishPact(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pact-go")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	pact := `{"consumer":{"name":"billy"},"provider":{"name":"bobby"},"interactions":[]}`
+	if err = ioutil.WriteFile(filepath.Join(dir, "billy-bobby.json"), []byte(pact), 0644); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return dir
+}
+
+func TestPublishCommand_Text(t *testing.T) {
+	broker, published := setupPublishBroker()
+	defer broker.Close()
+	dir := writePublishPact(t)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	err := publish(&out, types.PublishRequest{
+		PactURLs:            []string{dir},
+		PactBroker:          broker.URL,
+		ConsumerVersion:     "1.0.0",
+		Tags:                []string{"master"},
+		DisableGitBranchTag: true,
+	}, "text")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	expected := []string{"/pacts/provider/bobby/consumer/billy/version/1.0.0", "/pacticipants/billy/versions/1.0.0/tags/master"}
+	if !reflect.DeepEqual(*published, expected) {
+		t.Fatalf("expected requests %v, got %v", expected, *published)
+	}
+	for _, expected := range []string{"Published pact between billy and bobby to " + broker.URL + expected[0], "tagged with master"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but got '%s'", expected, out.String())
+		}
+	}
+}
+
+func TestPublishCommand_JSON(t *testing.T) {
+	broker, published := setupPublishBroker()
+	defer broker.Close()
+	dir := writePublishPact(t)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	err := publish(&out, types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		DryRun:          true,
+	}, "json")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(*published) != 0 {
+		t.Fatalf("expected nothing to be published, got %v", *published)
+	}
+	var res types.PublishResult
+	if err = json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !res.DryRun || len(res.Pacts) != 1 || res.Pacts[0].Consumer != "billy" || res.Pacts[0].Digest == "" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+}
+
+func TestPublishCommand_Fail(t *testing.T) {
+	var out bytes.Buffer
+	if err := publish(&out, types.PublishRequest{}, "yaml"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if err := publish(&out, types.PublishRequest{}, "text"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	// Failures are reported in the output
+	dir := writePublishPact(t)
+	defer os.RemoveAll(dir)
+	broker := httptest.NewServer(http.NotFoundHandler())
+	defer broker.Close()
+
+	out.Reset()
+	err := publish(&out, types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+	}, "json")
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if !strings.Contains(out.String(), `"error":`) {
+		t.Fatalf("expected the error in the output but got '%s'", out.String())
+	}
+}
+
+func TestPublishCommand_Args(t *testing.T) {
+	publishRequest.PactBroker = "http://broker"
+	publishRequest.Tags = []string{"master"}
+	defer func() {
+		publishRequest = types.PublishRequest{}
+	}()
+
+	env := map[string]string{
+		"PACT_BROKER_BASE_URL":  "http://other-broker",
+		"PACT_BROKER_TOKEN":     "abcd",
+		"PACT_CONSUMER_VERSION": "1.0.0",
+	}
+	request := publishArgs([]string{"./pacts"}, func(name string) string {
+		return env[name]
+	})
+
+	expected := types.PublishRequest{
+		PactURLs:        []string{"./pacts"},
+		PactBroker:      "http://broker",
+		BrokerToken:     "abcd",
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"master"},
+	}
+	if !reflect.DeepEqual(request, expected) {
+		t.Fatalf("expected request %+v, got %+v", expected, request)
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pact-foundation/pact-go/stub"
+	"github.com/spf13/cobra"
+)
+
+var stubHost string
+var stubPorts string
+var stubProviderState string
+var stubStateHeader string
+
+var stubCmd = &cobra.Command{
+	Use:   "stub PACT_FILES_OR_DIRS...",
+	Short: "Run a stub provider from pact files",
+	Long: `Runs a stub server until it is interrupted, responding to each request with
+the response of the interaction whose request matches it. Directories are
+expanded to the *.json pact files they contain.
+
+When several interactions match a request, the provider state given in the
+X-Pact-Provider-State header (or --provider-state-header) selects one of
+them. The interaction matched by each request is logged.`,
+	Example: `  pact-go stub ./pacts --port 8080`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		server, err := stub.NewServer(args...)
+		if err == nil {
+			server.ProviderState = stubProviderState
+			server.StateHeader = stubStateHeader
+			err = startStub(os.Stdout, server, stubHost, stubPorts)
+		}
+		if err != nil {
+			log.Println("[ERROR]", err)
+			os.Exit(1)
+		}
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+		server.Stop()
+	},
+}
+
+// startStub starts the stub server on the first free port of the allowed
+// ports, or any free port if none are given, writing its URL.
+func startStub(out io.Writer, server *stub.Server, host string, ports string) error {
+	port, err := findPort(ports)
+	if err != nil {
+		return fmt.Errorf("unable to find a free port for the stub server: %v", err)
+	}
+
+	if err = server.Start("tcp", fmt.Sprintf("%s:%d", host, port)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Stub server for %d interactions listening on %s\n", server.Interactions(), server.URL())
+
+	return nil
+}
+
+func init() {
+	stubCmd.Flags().StringVar(&stubHost, "host", "localhost", "Host to listen on")
+	stubCmd.Flags().StringVar(&stubPorts, "port", "", `Port to listen on, or ports to choose a free one from e.g. "8080,8081" or "8080-8090"`)
+	stubCmd.Flags().StringVar(&stubProviderState, "provider-state", "", "Provider state of the interactions to respond with, for requests without the provider state header")
+	stubCmd.Flags().StringVar(&stubStateHeader, "provider-state-header", stub.DefaultStateHeader, "Request header selecting the provider state of the interaction to respond with")
+	RootCmd.AddCommand(stubCmd)
+}
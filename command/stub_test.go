@@ -0,0 +1,56 @@
+package command
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/stub"
+)
+
+func TestStubCommand(t *testing.T) {
+	file := writeVerifyPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	server, err := stub.NewServer(filepath.Dir(file))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	var out bytes.Buffer
+	if err = startStub(&out, server, "localhost", ""); err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer server.Stop()
+
+	if !strings.Contains(out.String(), "Stub server for 1 interactions listening on "+server.URL()) {
+		t.Fatalf("expected output to contain the URL but got '%s'", out.String())
+	}
+
+	res, err := http.Get(server.URL() + "/users/1")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+}
+
+func TestStubCommand_Fail(t *testing.T) {
+	file := writeVerifyPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	server, err := stub.NewServer(file)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	var out bytes.Buffer
+	if err = startStub(&out, server, "localhost", "notaport"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -0,0 +1,185 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+	"github.com/spf13/cobra"
+)
+
+var verifyRequest = types.VerifyRequest{}
+var verifyProvider string
+var verifyNative bool
+var verifySelectors stringArray
+var verifyHeaders stringArray
+var verifyWIPPactsSince string
+
+// verifyEnv are the environment variables read for the flags that are not
+// set, and the fields of the request they set.
+var verifyEnv = map[string]func(*types.VerifyRequest) *string{
+	"PACT_BROKER_BASE_URL": func(r *types.VerifyRequest) *string { return &r.BrokerURL },
+	"PACT_BROKER_USERNAME": func(r *types.VerifyRequest) *string { return &r.BrokerUsername },
+	"PACT_BROKER_PASSWORD": func(r *types.VerifyRequest) *string { return &r.BrokerPassword },
+	"PACT_BROKER_TOKEN":    func(r *types.VerifyRequest) *string { return &r.BrokerToken },
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [PACT_URLS...]",
+	Short: "Verify pacts against a running provider",
+	Long: `Verifies the interactions of pact files or URLs against a running provider,
+exiting with a non-zero status if any of them fail.
+
+Instead of listing the pacts, they may be found in a Pact Broker with
+--broker-base-url and --provider, selecting the consumer versions with
+--consumer-version-tag or --consumer-version-selector, e.g.
+--consumer-version-selector '{"tag":"main","latest":true}'.
+
+The broker flags that are not set are read from the environment variables
+PACT_BROKER_BASE_URL, PACT_BROKER_USERNAME, PACT_BROKER_PASSWORD and
+PACT_BROKER_TOKEN.`,
+	Example: `  pact-go verify ./pacts/billy-bobby.json --provider-base-url http://localhost:8080
+  pact-go verify --provider bobby --provider-base-url http://localhost:8080 --broker-base-url http://broker --consumer-version-tag main`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		request, err := verifyArgs(args, os.Getenv)
+		if err == nil {
+			pact := &dsl.Pact{
+				Provider:               verifyProvider,
+				LogLevel:               logLevel,
+				NativeProviderVerifier: verifyNative,
+			}
+			err = verify(os.Stdout, pact, request)
+		}
+		if err != nil {
+			log.Println("[ERROR]", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// stringArray is a repeatable flag whose values are not split on commas, as
+// they may contain JSON or header values.
+type stringArray []string
+
+func (s *stringArray) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func (s *stringArray) String() string {
+	return "[" + strings.Join(*s, ", ") + "]"
+}
+
+func (s *stringArray) Type() string {
+	return "stringArray"
+}
+
+// verifyArgs builds the request from the command line arguments and flags,
+// falling back to the environment for the broker flags that are not set.
+func verifyArgs(args []string, getenv func(string) string) (types.VerifyRequest, error) {
+	request := verifyRequest
+	request.PactURLs = args
+	request.CustomProviderHeaders = verifyHeaders
+
+	for name, field := range verifyEnv {
+		if value := field(&request); *value == "" {
+			*value = getenv(name)
+		}
+	}
+
+	for _, selector := range verifySelectors {
+		var s types.ConsumerVersionSelector
+		if err := json.Unmarshal([]byte(selector), &s); err != nil {
+			return request, fmt.Errorf("invalid consumer version selector '%s': %v", selector, err)
+		}
+		request.ConsumerVersionSelectors = append(request.ConsumerVersionSelectors, s)
+	}
+
+	if verifyWIPPactsSince != "" {
+		since, err := time.Parse("2006-01-02", verifyWIPPactsSince)
+		if err != nil {
+			return request, fmt.Errorf("invalid date '%s' of work in progress pacts, must be formatted as 2006-01-02", verifyWIPPactsSince)
+		}
+		request.IncludeWIPPactsSince = &since
+	}
+
+	return request, nil
+}
+
+// verify runs the verification, writing the result of each interaction and a
+// summary. Failures of pending pacts are reported but do not fail it.
+func verify(out io.Writer, pact *dsl.Pact, request types.VerifyRequest) error {
+	if len(request.PactURLs) == 0 && request.BrokerURL == "" {
+		return fmt.Errorf("pact URLs or a Pact Broker URL are mandatory")
+	}
+
+	res, err := pact.VerifyProviderRaw(request)
+
+	failures, pending := 0, 0
+	for _, example := range res.Examples {
+		status := "PASSED"
+		switch {
+		case example.Status == "passed":
+		case example.Pending:
+			status = "PENDING"
+			pending++
+		default:
+			status = "FAILED"
+			failures++
+		}
+		fmt.Fprintf(out, "%-8s %s\n", status, example.FullDescription)
+		if example.Status != "passed" && example.Exception.Message != "" {
+			for _, line := range strings.Split(strings.TrimRight(example.Exception.Message, "\n"), "\n") {
+				fmt.Fprintf(out, "         %s\n", line)
+			}
+		}
+	}
+
+	if len(res.Examples) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "%d interactions, %d failures", len(res.Examples), failures)
+		if pending > 0 {
+			fmt.Fprintf(out, ", %d pending", pending)
+		}
+		fmt.Fprintln(out)
+	}
+
+	if err == nil && failures > 0 {
+		err = fmt.Errorf("%d of %d interactions failed verification", failures, len(res.Examples))
+	}
+
+	return err
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyProvider, "provider", "", "Name of the provider, to find its pacts in the Pact Broker")
+	verifyCmd.Flags().StringVar(&verifyRequest.ProviderBaseURL, "provider-base-url", "", "URL of the running provider to verify")
+	verifyCmd.Flags().StringVar(&verifyRequest.ProviderStatesSetupURL, "provider-states-setup-url", "", "URL to post the provider state of each interaction to")
+	verifyCmd.Flags().Var(&verifyHeaders, "custom-provider-header", "Header to add to each request to the provider e.g. 'Authorization: Basic cGFjdDpwYWN0' (may be repeated)")
+	verifyCmd.Flags().StringVarP(&verifyRequest.BrokerURL, "broker-base-url", "b", "", "URL of the Pact Broker to find pacts in")
+	verifyCmd.Flags().StringVarP(&verifyRequest.BrokerUsername, "broker-username", "u", "", "Username for Pact Broker basic authentication")
+	verifyCmd.Flags().StringVarP(&verifyRequest.BrokerPassword, "broker-password", "p", "", "Password for Pact Broker basic authentication")
+	verifyCmd.Flags().StringVarP(&verifyRequest.BrokerToken, "broker-token", "k", "", "Token for Pact Broker bearer authentication")
+	verifyCmd.Flags().StringVar(&verifyRequest.BrokerTransport.CACertFile, "ca-cert", "", "PEM bundle of CA certificates to trust when connecting to the Pact Broker")
+	verifyCmd.Flags().StringVar(&verifyRequest.BrokerTransport.ClientCertFile, "client-cert", "", "PEM client certificate to authenticate to the Pact Broker with")
+	verifyCmd.Flags().StringVar(&verifyRequest.BrokerTransport.ClientKeyFile, "client-key", "", "PEM private key of the client certificate")
+	verifyCmd.Flags().StringVar(&verifyRequest.BrokerTransport.ProxyURL, "proxy", "", "Proxy to connect to the Pact Broker through")
+	verifyCmd.Flags().StringSliceVar(&verifyRequest.Tags, "consumer-version-tag", nil, "Tag of the latest consumer versions to verify (may be repeated)")
+	verifyCmd.Flags().Var(&verifySelectors, "consumer-version-selector", "JSON selector of the consumer versions to verify (may be repeated)")
+	verifyCmd.Flags().BoolVar(&verifyRequest.EnablePending, "enable-pending", false, "Verify pending pacts without their failures failing the verification")
+	verifyCmd.Flags().StringVar(&verifyWIPPactsSince, "include-wip-pacts-since", "", "Also verify the work in progress pacts published since a date e.g. 2020-01-31")
+	verifyCmd.Flags().StringVar(&verifyRequest.ProviderVersion, "provider-app-version", "", "Version of the provider being verified")
+	verifyCmd.Flags().StringSliceVar(&verifyRequest.ProviderVersionTags, "provider-version-tag", nil, "Tag of the provider version being verified (may be repeated)")
+	verifyCmd.Flags().BoolVar(&verifyRequest.PublishVerificationResults, "publish-verification-results", false, "Publish the verification results to the Pact Broker")
+	verifyCmd.Flags().StringVar(&verifyRequest.BuildURL, "build-url", "", "URL of the build running the verification, published with the results")
+	verifyCmd.Flags().BoolVar(&verifyNative, "native", false, "Use the native Go verifier rather than the Ruby verifier")
+	RootCmd.AddCommand(verifyCmd)
+}
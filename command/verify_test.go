@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/dsl"
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func setupVerifyProvider() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/users/1" || req.Header.Get("Authorization") != "Bearer 1234" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"sally"}`))
+	}))
+}
+
+func writeVerifyPact(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pact-go")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	pact := `{
+		"consumer": {"name": "billy"},
+		"provider": {"name": "bobby"},
+		"interactions": [{
+			"description": "A request for user 1",
+			"request": {"method": "GET", "path": "/users/1"},
+			"response": {"status": 200, "headers": {"Content-Type": "application/json"}, "body": {"name": "sally"}}
+		}],
+		"metadata": {"pactSpecification": {"version": "2.0.0"}}
+	}`
+	file := filepath.Join(dir, "billy-bobby.json")
+	if err = ioutil.WriteFile(file, []byte(pact), 0644); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return file
+}
+
+func TestVerifyCommand(t *testing.T) {
+	provider := setupVerifyProvider()
+	defer provider.Close()
+	file := writeVerifyPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var out bytes.Buffer
+	err := verify(&out, &dsl.Pact{Provider: "bobby", NativeProviderVerifier: true}, types.VerifyRequest{
+		ProviderBaseURL:       provider.URL,
+		PactURLs:              []string{file},
+		CustomProviderHeaders: []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, expected := range []string{"PASSED", "A request for user 1", "1 interactions, 0 failures"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but got '%s'", expected, out.String())
+		}
+	}
+}
+
+func TestVerifyCommand_Fail(t *testing.T) {
+	provider := setupVerifyProvider()
+	defer provider.Close()
+	file := writeVerifyPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var out bytes.Buffer
+	if err := verify(&out, &dsl.Pact{}, types.VerifyRequest{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	err := verify(&out, &dsl.Pact{Provider: "bobby", NativeProviderVerifier: true}, types.VerifyRequest{
+		ProviderBaseURL: provider.URL,
+		PactURLs:        []string{file},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	for _, expected := range []string{"FAILED", "A request for user 1", "1 interactions, 1 failures"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected output to contain '%s' but got '%s'", expected, out.String())
+		}
+	}
+}
+
+func TestVerifyCommand_Args(t *testing.T) {
+	verifyRequest.ProviderBaseURL = "http://localhost:8080"
+	verifyHeaders = stringArray{"Authorization: Basic cGFjdDpwYWN0"}
+	verifySelectors = stringArray{`{"tag":"main","latest":true}`, `{"deployed":true,"environment":"prod"}`}
+	verifyWIPPactsSince = "2020-01-31"
+	defer func() {
+		verifyRequest = types.VerifyRequest{}
+		verifyHeaders = nil
+		verifySelectors = nil
+		verifyWIPPactsSince = ""
+	}()
+
+	request, err := verifyArgs([]string{"./pacts/billy-bobby.json"}, func(name string) string {
+		return map[string]string{"PACT_BROKER_BASE_URL": "http://broker"}[name]
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	since := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	expected := types.VerifyRequest{
+		ProviderBaseURL:       "http://localhost:8080",
+		PactURLs:              []string{"./pacts/billy-bobby.json"},
+		BrokerURL:             "http://broker",
+		CustomProviderHeaders: []string{"Authorization: Basic cGFjdDpwYWN0"},
+		ConsumerVersionSelectors: []types.ConsumerVersionSelector{
+			{Tag: "main", Latest: true},
+			{Deployed: true, Environment: "prod"},
+		},
+		IncludeWIPPactsSince: &since,
+	}
+	if !reflect.DeepEqual(request, expected) {
+		t.Fatalf("expected request %+v, got %+v", expected, request)
+	}
+
+	verifySelectors = stringArray{"tag=main"}
+	if _, err = verifyArgs(nil, os.Getenv); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -1,28 +1,29 @@
 package dsl
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
-	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/pact-foundation/pact-go/broker"
 	"github.com/pact-foundation/pact-go/types"
 )
 
 var (
-	pactURLPattern        = "%s/pacts/provider/%s/latest"
-	pactURLPatternWithTag = "%s/pacts/provider/%s/latest/%s"
-
 	// ErrNoConsumers is returned when no consumer are not found for a provider.
 	ErrNoConsumers = errors.New("no consumers found")
 
 	// ErrUnauthorized represents a Forbidden (403).
-	ErrUnauthorized = errors.New("unauthorized")
+	ErrUnauthorized = broker.ErrUnauthorized
 )
 
 // PactLink represents the Pact object in the HAL response.
+// Deprecated: use the broker package to navigate a Pact Broker.
 type PactLink struct {
 	Href  string `json:"href"`
 	Title string `json:"title"`
@@ -30,16 +31,99 @@ type PactLink struct {
 }
 
 // HalLinks represents the _links key in a HAL document.
+// Deprecated: use the broker package to navigate a Pact Broker.
 type HalLinks struct {
 	Pacts    []PactLink `json:"pb:pacts"`
 	OldPacts []PactLink `json:"pacts"`
 }
 
 // HalDoc is a simple representation of the HAL response from a Pact Broker.
+// Deprecated: use the broker package to navigate a Pact Broker.
 type HalDoc struct {
 	Links HalLinks `json:"_links"`
 }
 
+// brokerClient creates a client for a Pact Broker, authenticating with the
+// token, or the username and password, if given.
+func brokerClient(brokerURL string, username string, password string, token string, transport types.BrokerTransport) (*broker.Client, error) {
+	client := &broker.Client{
+		BrokerURL: brokerURL,
+	}
+
+	switch {
+	case token != "" && (username != "" || password != ""):
+		return nil, errors.New("BrokerToken cannot be used with BrokerUsername and BrokerPassword")
+	case token != "":
+		client.Auth = broker.BearerAuth{Token: token}
+	case username != "" && password != "":
+		client.Auth = broker.BasicAuth{Username: username, Password: password}
+	}
+
+	if transport != (types.BrokerTransport{}) {
+		httpClient, err := broker.NewHTTPClient(transport)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = httpClient
+		client.Timeout = transport.Timeout
+		client.MaxRetries = transport.MaxRetries
+		client.RetryBackoff = transport.RetryBackoff
+	}
+
+	return client, nil
+}
+
+// verificationBrokerClient creates a client for the Pact Broker of a
+// verification request, which sends its requests with the context.
+func verificationBrokerClient(ctx context.Context, request types.VerifyRequest) (*broker.Client, error) {
+	client, err := brokerClient(verificationBrokerURL(request), request.BrokerUsername, request.BrokerPassword, request.BrokerToken, request.BrokerTransport)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WithContext(ctx), nil
+}
+
+// downloadPacts fetches the Pacts of a request from their URLs into a
+// temporary directory before verifying them, for verifiers that cannot
+// connect to the Pact Broker with its BrokerTransport.
+func downloadPacts(ctx context.Context, verify func(types.VerifyRequest) (types.ProviderVerifierResponse, error)) func(types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+	return func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		client, err := verificationBrokerClient(ctx, request)
+		if err != nil {
+			return types.ProviderVerifierResponse{}, err
+		}
+
+		dir, err := ioutil.TempDir("", "pact-go")
+		if err != nil {
+			return types.ProviderVerifierResponse{}, err
+		}
+		defer os.RemoveAll(dir)
+
+		pactURLs := make([]string, len(request.PactURLs))
+		for i, pactURL := range request.PactURLs {
+			if !isURL(pactURL) {
+				pactURLs[i] = pactURL
+				continue
+			}
+
+			log.Println("[DEBUG] broker - downloading pact:", pactURL)
+			_, data, err := client.Pact(pactURL)
+			if err != nil {
+				return types.ProviderVerifierResponse{}, fmt.Errorf("unable to fetch pact file %s: %v", pactURL, err)
+			}
+
+			pactURLs[i] = filepath.Join(dir, fmt.Sprintf("pact-%d.json", i))
+			if err = ioutil.WriteFile(pactURLs[i], data, 0644); err != nil {
+				return types.ProviderVerifierResponse{}, err
+			}
+		}
+
+		request.PactURLs = pactURLs
+		return verify(request)
+	}
+}
+
 // findConsumers navigates a Pact Broker's HAL system to find consumers
 // based on the latest Pacts or using tags.
 //
@@ -48,78 +132,233 @@ type HalDoc struct {
 //   1. Ask for all 'latest' consumers
 //   2. Pass a set of tags (e.g. 'latest' and 'prod') and find all consumers
 //      that match
-func findConsumers(provider string, request *types.VerifyRequest) error {
+//
+// If the request has ConsumerVersionSelectors, or pending Pacts are enabled,
+// the Pacts selected by the Broker are used instead.
+func findConsumers(ctx context.Context, provider string, request *types.VerifyRequest) error {
+	if usePactsForVerification(*request) {
+		_, err := findPactsForVerification(ctx, provider, request)
+		return err
+	}
+
 	log.Println("[DEBUG] broker - find consumers for provider:", provider)
 
-	client := &http.Client{}
-	var urls []string
+	client, err := verificationBrokerClient(ctx, *request)
+	if err != nil {
+		return err
+	}
 	pactURLs := make(map[string]string)
 
-	if len(request.Tags) > 0 {
-		for _, tag := range request.Tags {
-			urls = append(urls, fmt.Sprintf(pactURLPatternWithTag, request.BrokerURL, provider, tag))
-		}
-	} else {
-		urls = append(urls, fmt.Sprintf(pactURLPattern, request.BrokerURL, provider))
+	tags := request.Tags
+	if len(tags) == 0 {
+		tags = []string{""}
 	}
 
-	for _, url := range urls {
-		var req *http.Request
-		req, err := http.NewRequest("GET", url, nil)
+	for _, tag := range tags {
+		links, err := client.LatestPacts(provider, tag)
+		if err == broker.ErrNotFound {
+			return ErrNoConsumers
+		}
 		if err != nil {
 			return err
 		}
 
-		req.Header.Set("Accept", "application/hal+json")
-
-		if request.BrokerUsername != "" && request.BrokerPassword != "" {
-			req.SetBasicAuth(request.BrokerUsername, request.BrokerPassword)
+		// Collapse results on the URL the pact links to
+		for _, p := range links {
+			pactURLs[p.Href] = p.Href
 		}
+	}
 
-		res, err := client.Do(req)
-		if err != nil {
-			return err
+	// Scrub out duplicate pacts across tags (e.g. 'latest' may equal 'prod' pact)
+	for _, p := range pactURLs {
+		request.PactURLs = append(request.PactURLs, p)
+	}
+
+	fmt.Println("[DEBUG] discovered pacts to verify: ", request.PactURLs)
+
+	return nil
+}
+
+// usePactsForVerification reports whether the Pacts to verify are selected
+// by the Broker's "pacts for verification" API, which is needed for the
+// Consumer version selectors and pending Pacts.
+func usePactsForVerification(request types.VerifyRequest) bool {
+	return len(request.ConsumerVersionSelectors) > 0 || request.EnablePending || request.IncludeWIPPactsSince != nil
+}
+
+// findPacts finds the Pacts to verify in a Pact Broker, returning the URLs of
+// those that are pending.
+func findPacts(ctx context.Context, provider string, request *types.VerifyRequest) (map[string]bool, error) {
+	log.Println("[DEBUG] pact provider verification - finding all consumers from broker: ", request.BrokerURL)
+
+	if !usePactsForVerification(*request) {
+		return nil, findConsumers(ctx, provider, request)
+	}
+
+	pacts, err := findPactsForVerification(ctx, provider, request)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]bool)
+	for _, pact := range pacts {
+		if pact.VerificationProperties.Pending || pact.VerificationProperties.WIP {
+			pending[pact.URL()] = true
 		}
+	}
 
-		switch res.StatusCode {
-		case 401:
-			return ErrUnauthorized
-		case 404:
-			return ErrNoConsumers
+	return pending, nil
+}
+
+// findPactsForVerification asks a Pact Broker for the Pacts to verify with the
+// Consumer version selectors of the request, which are exactly the Pacts
+// that are verified. The notices of the Broker about each Pact are logged.
+//
+// Tags are converted to selectors for the latest Pact with each tag.
+func findPactsForVerification(ctx context.Context, provider string, request *types.VerifyRequest) ([]broker.PactForVerification, error) {
+	log.Println("[DEBUG] broker - find pacts for verification for provider:", provider)
+
+	selectors := request.ConsumerVersionSelectors
+	if len(request.Tags) > 0 {
+		if len(selectors) > 0 {
+			return nil, errors.New("Tags and ConsumerVersionSelectors cannot be used together")
 		}
+		for _, tag := range request.Tags {
+			selectors = append(selectors, types.ConsumerVersionSelector{Tag: tag, Latest: true})
+		}
+	}
 
-		responseBody, err := ioutil.ReadAll(res.Body)
-		res.Body.Close()
-		log.Printf("[DEBUG] pact broker response Body: %s\n", responseBody)
+	query := broker.PactsForVerificationRequest{
+		ConsumerVersionSelectors: selectors,
+		ProviderVersionTags:      request.ProviderVersionTags,
+		IncludePendingStatus:     request.EnablePending || request.IncludeWIPPactsSince != nil,
+	}
+	if request.IncludeWIPPactsSince != nil {
+		query.IncludeWIPPactsSince = request.IncludeWIPPactsSince.Format(time.RFC3339)
+	}
 
-		if res.StatusCode < 200 || res.StatusCode >= 300 {
-			return errors.New(string(responseBody))
+	client, err := verificationBrokerClient(ctx, *request)
+	if err != nil {
+		return nil, err
+	}
+	pacts, err := client.PactsForVerification(provider, query)
+	if err == broker.ErrNotFound {
+		return nil, ErrNoConsumers
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pacts) == 0 {
+		return nil, ErrNoConsumers
+	}
+
+	for _, pact := range pacts {
+		for _, notice := range pact.VerificationProperties.Notices {
+			log.Printf("[INFO] %s: %s", pact.ShortDescription, notice.Text)
 		}
+		request.PactURLs = append(request.PactURLs, pact.URL())
+	}
+
+	log.Println("[DEBUG] discovered pacts to verify: ", request.PactURLs)
 
-		var doc HalDoc
-		err = json.Unmarshal(responseBody, &doc)
+	return pacts, nil
+}
+
+// CanIDeploy asks a Pact Broker whether the selected application versions
+// are safe to deploy, based on the verification results between them in the
+// matrix.
+//
+// While verification results are unknown, the Broker is asked again up to
+// RetryWhileUnknown times, to allow for provider builds that are running.
+func CanIDeploy(request types.CanIDeployRequest) (types.CanIDeployResponse, error) {
+	return CanIDeployContext(context.Background(), request)
+}
+
+// CanIDeployContext is CanIDeploy with a context, which cancels the requests
+// to the Pact Broker and the wait between retries.
+func CanIDeployContext(ctx context.Context, request types.CanIDeployRequest) (types.CanIDeployResponse, error) {
+	log.Println("[DEBUG] broker - can i deploy")
+
+	if err := request.Validate(); err != nil {
+		return types.CanIDeployResponse{}, err
+	}
+
+	// With a single version, check it against the latest verification of
+	// each of its Consumers and Providers
+	query := broker.MatrixQuery{
+		LatestBy: "cvpv",
+	}
+	if len(request.Selectors) == 1 {
+		query.LatestBy = "cvp"
+	}
+	if request.To != "" {
+		query.Latest = true
+		query.Tag = request.To
+	}
+	for _, selector := range request.Selectors {
+		query.Selectors = append(query.Selectors, broker.Selector{
+			Pacticipant: selector.Pacticipant,
+			Version:     selector.Version,
+			Latest:      selector.Latest,
+			Tag:         selector.Tag,
+		})
+	}
+
+	client, err := brokerClient(request.BrokerURL, request.BrokerUsername, request.BrokerPassword, request.BrokerToken, request.BrokerTransport)
+	if err != nil {
+		return types.CanIDeployResponse{}, err
+	}
+	client = client.WithContext(ctx)
+	for attempt := 0; ; attempt++ {
+		matrix, err := client.Matrix(query)
 		if err != nil {
-			return err
+			return types.CanIDeployResponse{}, err
 		}
 
-		// Collapse results on the URL the pact links to
-		for _, p := range doc.Links.Pacts {
-			pactURLs[p.Href] = p.Href
+		response := canIDeployResponse(matrix)
+		if response.Summary.Unknown == 0 || attempt >= request.RetryWhileUnknown {
+			return response, nil
 		}
 
-		// Ensure backwards compatability with old pacts
-		// See https://github.com/pact-foundation/pact_broker/issues/209#issuecomment-390437990
-		for _, p := range doc.Links.OldPacts {
-			pactURLs[p.Href] = p.Href
+		log.Printf("[INFO] waiting for %d verification result(s) to be published, retrying in %v", response.Summary.Unknown, request.RetryInterval)
+		select {
+		case <-ctx.Done():
+			return response, ctx.Err()
+		case <-time.After(request.RetryInterval):
 		}
 	}
+}
 
-	// Scrub out duplicate pacts across tags (e.g. 'latest' may equal 'prod' pact)
-	for _, p := range pactURLs {
-		request.PactURLs = append(request.PactURLs, p)
+// canIDeployResponse converts the matrix of the Broker into the result of a
+// can-i-deploy check.
+func canIDeployResponse(matrix *broker.Matrix) types.CanIDeployResponse {
+	response := types.CanIDeployResponse{
+		Deployable: matrix.Summary.Deployable != nil && *matrix.Summary.Deployable,
+		Reason:     matrix.Summary.Reason,
+		Summary: types.CanIDeploySummary{
+			Success: matrix.Summary.Success,
+			Failed:  matrix.Summary.Failed,
+			Unknown: matrix.Summary.Unknown,
+		},
 	}
 
-	fmt.Println("[DEBUG] discovered pacts to verify: ", request.PactURLs)
+	for _, row := range matrix.Matrix {
+		result := types.CanIDeployResult{
+			Consumer:        row.Consumer.Name,
+			ConsumerVersion: row.Consumer.Version.Number,
+			Provider:        row.Provider.Name,
+			ProviderVersion: row.Provider.Version.Number,
+			Status:          "unknown",
+		}
+		if row.VerificationResult != nil {
+			result.VerifiedAt = row.VerificationResult.VerifiedAt
+			result.Status = "failed"
+			if row.VerificationResult.Success {
+				result.Status = "success"
+			}
+		}
+		response.Matrix = append(response.Matrix, result)
+	}
 
-	return nil
+	return response
 }
@@ -1,12 +1,20 @@
 package dsl
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/pact-foundation/pact-go/broker"
 	"github.com/pact-foundation/pact-go/types"
 	"github.com/pact-foundation/pact-go/utils"
 )
@@ -17,7 +25,7 @@ func TestBroker_findConsumersNoTags(t *testing.T) {
 	request := types.VerifyRequest{
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("bobby", &request)
+	err := findConsumers(context.Background(), "bobby", &request)
 	if err != nil {
 		t.Fatalf("Error: %s", err.Error())
 	}
@@ -36,7 +44,7 @@ func TestBroker_findConsumersWithTags(t *testing.T) {
 		Tags:      []string{"dev", "prod"},
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("bobby", &request)
+	err := findConsumers(context.Background(), "bobby", &request)
 	if err != nil {
 		t.Fatalf("Error: %s", err.Error())
 	}
@@ -52,7 +60,7 @@ func TestBroker_findConsumersBrokerDown(t *testing.T) {
 		Tags:      []string{"dev", "prod"},
 		BrokerURL: fmt.Sprintf("http://localhost:%d", port),
 	}
-	err := findConsumers("idontexist", &request)
+	err := findConsumers(context.Background(), "idontexist", &request)
 
 	if err == nil {
 		t.Fatalf("Expected error but got none")
@@ -66,7 +74,7 @@ func TestBroker_findConsumersInvalidResponse(t *testing.T) {
 		Tags:      []string{"broken"},
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("bobby", &request)
+	err := findConsumers(context.Background(), "bobby", &request)
 
 	if err == nil {
 		t.Fatalf("Expected error but got none")
@@ -77,7 +85,7 @@ func TestBroker_findConsumersInvalidURL(t *testing.T) {
 	request := types.VerifyRequest{
 		BrokerURL: "%%%",
 	}
-	err := findConsumers("broken", &request)
+	err := findConsumers(context.Background(), "broken", &request)
 
 	if err == nil {
 		t.Fatalf("Expected error but got none")
@@ -91,7 +99,7 @@ func TestBroker_findConsumersErrorResponse(t *testing.T) {
 		Tags:      []string{"dev"},
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("broken", &request)
+	err := findConsumers(context.Background(), "broken", &request)
 
 	if err == nil {
 		t.Fatalf("Expected error but got none")
@@ -105,7 +113,7 @@ func TestBroker_findConsumersNoConsumers(t *testing.T) {
 		Tags:      []string{"dev", "prod"},
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("idontexist", &request)
+	err := findConsumers(context.Background(), "idontexist", &request)
 	if err == nil {
 		t.Fatalf("Expected error but got none")
 	}
@@ -120,7 +128,21 @@ func TestBroker_findConsumersAuthenticated(t *testing.T) {
 		BrokerUsername: "foo",
 		BrokerPassword: "bar",
 	}
-	err := findConsumers("bobby", &request)
+	err := findConsumers(context.Background(), "bobby", &request)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+}
+
+func TestBroker_findConsumersWithToken(t *testing.T) {
+	s := setupMockBroker(true)
+	defer s.Close()
+	request := types.VerifyRequest{
+		Tags:        []string{"dev", "prod"},
+		BrokerURL:   s.URL,
+		BrokerToken: "abcd",
+	}
+	err := findConsumers(context.Background(), "bobby", &request)
 	if err != nil {
 		t.Fatalf("Error: %s", err)
 	}
@@ -133,7 +155,7 @@ func TestBroker_findConsumersAuthenticatedFail(t *testing.T) {
 		Tags:      []string{"dev", "prod"},
 		BrokerURL: s.URL,
 	}
-	err := findConsumers("bobby", &request)
+	err := findConsumers(context.Background(), "bobby", &request)
 
 	switch err {
 	case ErrUnauthorized:
@@ -142,6 +164,233 @@ func TestBroker_findConsumersAuthenticatedFail(t *testing.T) {
 	}
 }
 
+func TestBroker_findConsumersWithSelectors(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	request := types.VerifyRequest{
+		BrokerURL:                s.URL,
+		ConsumerVersionSelectors: []types.ConsumerVersionSelector{{Tag: "prod"}, {Branch: "main", Latest: true}},
+	}
+	err := findConsumers(context.Background(), "bobby", &request)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+
+	expected := []string{
+		s.URL + "/pacts/provider/bobby/consumer/billy/version/1.0.0",
+		s.URL + "/pacts/provider/bobby/consumer/billy/version/1.0.1",
+	}
+	if !reflect.DeepEqual(request.PactURLs, expected) {
+		t.Fatalf("Expected PactURLs %v but got: %v", expected, request.PactURLs)
+	}
+}
+
+func TestBroker_findConsumersWithSelectorsFail(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+
+	request := types.VerifyRequest{
+		BrokerURL:                s.URL,
+		Tags:                     []string{"prod"},
+		ConsumerVersionSelectors: []types.ConsumerVersionSelector{{Tag: "prod"}},
+	}
+	if err := findConsumers(context.Background(), "bobby", &request); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	request = types.VerifyRequest{
+		BrokerURL:                s.URL,
+		ConsumerVersionSelectors: []types.ConsumerVersionSelector{{Tag: "prod"}},
+	}
+	if err := findConsumers(context.Background(), "idontexist", &request); err != ErrNoConsumers {
+		t.Fatalf("Expected error to be 'ErrNoConsumers' but got %v", err)
+	}
+}
+
+func TestBroker_findPactsPending(t *testing.T) {
+	var body map[string]interface{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&body)
+		fmt.Fprintf(w, `{"_embedded":{"pacts":[
+			{"shortDescription":"latest with tag dev","verificationProperties":{"pending":false},"_links":{"self":{"href":"%[1]s/pacts/1"}}},
+			{"shortDescription":"latest with tag dev","verificationProperties":{"pending":true},"_links":{"self":{"href":"%[1]s/pacts/2"}}},
+			{"shortDescription":"work in progress","verificationProperties":{"pending":true,"wip":true},"_links":{"self":{"href":"%[1]s/pacts/3"}}}
+		]}}`, server.URL)
+	}))
+	defer server.Close()
+
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	request := types.VerifyRequest{
+		BrokerURL:            server.URL,
+		Tags:                 []string{"dev"},
+		EnablePending:        true,
+		IncludeWIPPactsSince: &since,
+		ProviderVersionTags:  []string{"main"},
+	}
+	pending, err := findPacts(context.Background(), "bobby", &request)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(request.PactURLs) != 3 || len(pending) != 2 || !pending[server.URL+"/pacts/2"] || !pending[server.URL+"/pacts/3"] {
+		t.Fatalf("Expected 2 of 3 pacts to be pending but got: %v, %v", request.PactURLs, pending)
+	}
+
+	expected := map[string]interface{}{
+		"consumerVersionSelectors": []interface{}{map[string]interface{}{"tag": "dev", "latest": true}},
+		"providerVersionTags":      []interface{}{"main"},
+		"includePendingStatus":     true,
+		"includeWipPactsSince":     "2020-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Fatalf("Expected request %v but got: %v", expected, body)
+	}
+}
+
+func TestBroker_findPactsNotPending(t *testing.T) {
+	s := setupMockBroker(false)
+	defer s.Close()
+	request := types.VerifyRequest{
+		BrokerURL: s.URL,
+		Tags:      []string{"dev"},
+	}
+
+	pending, err := findPacts(context.Background(), "bobby", &request)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(pending) != 0 || len(request.PactURLs) != 1 {
+		t.Fatalf("Expected 1 PactURL and none pending but got: %v, %v", request.PactURLs, pending)
+	}
+}
+
+func TestBroker_CanIDeploy(t *testing.T) {
+	broker, queries := setupMatrixBroker(`{"deployable":true,"reason":"All verification results are published and successful","success":1,"failed":0,"unknown":0}`,
+		`{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":{"success":true,"verifiedAt":"2018-06-01T00:00:00+00:00"}}`)
+	defer broker.Close()
+
+	res, err := CanIDeploy(types.CanIDeployRequest{
+		BrokerURL: broker.URL,
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+		To:        "prod",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if !res.Deployable || len(res.Matrix) != 1 || res.Matrix[0].Status != "success" || res.Matrix[0].ProviderVersion != "2.0.0" {
+		t.Fatalf("expected a deployable result, got %+v", res)
+	}
+	expected := "q%5B%5D%5Bpacticipant%5D=billy&q%5B%5D%5Bversion%5D=1.0.0&latestby=cvp&latest=true&tag=prod"
+	if (*queries)[0] != expected {
+		t.Fatalf("expected query %s, got %s", expected, (*queries)[0])
+	}
+}
+
+func TestBroker_CanIDeployNotDeployable(t *testing.T) {
+	broker, _ := setupMatrixBroker(`{"deployable":false,"reason":"One or more verifications failed","success":0,"failed":1,"unknown":0}`,
+		`{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":{"number":"2.0.0"}},"verificationResult":{"success":false}}`)
+	defer broker.Close()
+
+	res, err := CanIDeploy(types.CanIDeployRequest{
+		BrokerURL: broker.URL,
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}, {Pacticipant: "bobby", Latest: true}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if res.Deployable || res.Reason != "One or more verifications failed" || res.Matrix[0].Status != "failed" {
+		t.Fatalf("expected an undeployable result, got %+v", res)
+	}
+}
+
+func TestBroker_CanIDeployRetryWhileUnknown(t *testing.T) {
+	broker, queries := setupMatrixBroker(`{"deployable":null,"reason":"Missing one or more verification results","success":0,"failed":0,"unknown":1}`,
+		`{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":null},"verificationResult":null}`)
+	defer broker.Close()
+
+	res, err := CanIDeploy(types.CanIDeployRequest{
+		BrokerURL:         broker.URL,
+		Selectors:         []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+		RetryWhileUnknown: 2,
+		RetryInterval:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if res.Deployable || res.Matrix[0].Status != "unknown" {
+		t.Fatalf("expected an unknown result, got %+v", res)
+	}
+	if len(*queries) != 3 {
+		t.Fatalf("expected the matrix to be queried 3 times, got %d", len(*queries))
+	}
+}
+
+func TestBroker_CanIDeployContextCancelled(t *testing.T) {
+	broker, queries := setupMatrixBroker(`{"deployable":null,"reason":"Missing one or more verification results","success":0,"failed":0,"unknown":1}`,
+		`{"consumer":{"name":"billy","version":{"number":"1.0.0"}},"provider":{"name":"bobby","version":null},"verificationResult":null}`)
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := CanIDeployContext(ctx, types.CanIDeployRequest{
+		BrokerURL:         broker.URL,
+		Selectors:         []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+		RetryWhileUnknown: 10,
+		RetryInterval:     time.Hour,
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected the context deadline to be exceeded, got %v", err)
+	}
+	if len(*queries) != 1 {
+		t.Fatalf("expected the matrix to be queried once, got %d", len(*queries))
+	}
+}
+
+func TestBroker_CanIDeployFail(t *testing.T) {
+	_, err := CanIDeploy(types.CanIDeployRequest{
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	_, err = CanIDeploy(types.CanIDeployRequest{
+		BrokerURL: "http://localhost",
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy"}},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	s := setupMockBroker(true)
+	defer s.Close()
+	_, err = CanIDeploy(types.CanIDeployRequest{
+		BrokerURL: s.URL,
+		Selectors: []types.CanIDeploySelector{{Pacticipant: "billy", Version: "1.0.0"}},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+// setupMatrixBroker pretends to be a Broker with a matrix of a single row,
+// recording the matrix queries.
+func setupMatrixBroker(summary string, row string) (*httptest.Server, *[]string) {
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/matrix", func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.RawQuery)
+		w.Header().Add("Content-Type", "application/hal+json")
+		fmt.Fprintf(w, `{"summary":%s,"matrix":[%s]}`, summary, row)
+	})
+
+	return httptest.NewServer(mux), &queries
+}
+
 // Pretend to be a Broker for fetching Pacts
 func setupMockBroker(auth bool) *httptest.Server {
 	mux := http.NewServeMux()
@@ -190,6 +439,13 @@ func setupMockBroker(auth bool) *httptest.Server {
 		w.Header().Add("Content-Type", "application/hal+json")
 	}))
 
+	// Pacts for verification of 'bobby', selected by tag and branch
+	mux.Handle("/pacts/provider/bobby/for-verification", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		log.Println("[DEBUG] get pacts for verification for provider 'bobby'")
+		fmt.Fprintf(w, `{"_embedded":{"pacts":[{"shortDescription":"latest with tag prod","verificationProperties":{"notices":[{"when":"before_verification","text":"The pact is being verified because it matches the selector {tag: prod}"}]},"_links":{"self":{"href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0","name":"Pact between billy (1.0.0) and bobby"}}},{"shortDescription":"latest from branch main","verificationProperties":{"notices":[]},"_links":{"self":{"href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.1","name":"Pact between billy (1.0.1) and bobby"}}}]}}`, server.URL, server.URL)
+		w.Header().Add("Content-Type", "application/hal+json")
+	}))
+
 	// Broken response
 	mux.Handle("/pacts/provider/bobby/latest/broken", authFunc(func(w http.ResponseWriter, req *http.Request) {
 		log.Println("[DEBUG] broken broker")
@@ -217,9 +473,102 @@ func setupMockBroker(auth bool) *httptest.Server {
 	// curl -v --user pactuser:pact -H "accept: application/json" http://pact.onegeek.com.au/pacts/provider/bobby/consumer/billy/version/1.0.0
 	mux.Handle("/pacts/provider/bobby/consumer/billy/version/", authFunc(func(w http.ResponseWriter, req *http.Request) {
 		log.Println("[DEBUG] get all pacts for provider 'bobby' where any tag exists")
-		fmt.Fprintf(w, `{"consumer":{"name":"billy"},"provider":{"name":"bobby"},"interactions":[{"description":"Some name for the test","provider_state":"Some state","request":{"method":"GET","path":"/foobar"},"response":{"status":200,"headers":{"Content-Type":"application/json"}}},{"description":"Some name for the test","provider_state":"Some state2","request":{"method":"GET","path":"/bazbat"},"response":{"status":200,"headers":{},"body":[[{"colour":"red","size":10,"tag":[["jumper","shirt"],["jumper","shirt"]]}]],"matchingRules":{"$.body":{"min":1},"$.body[*].*":{"match":"type"},"$.body[*]":{"min":1},"$.body[*][*].*":{"match":"type"},"$.body[*][*].colour":{"match":"regex","regex":"red|green|blue"},"$.body[*][*].size":{"match":"type"},"$.body[*][*].tag":{"min":2},"$.body[*][*].tag[*].*":{"match":"type"},"$.body[*][*].tag[*][0]":{"match":"type"},"$.body[*][*].tag[*][1]":{"match":"type"}}}}],"metadata":{"pactSpecificationVersion":"2.0.0"},"updatedAt":"2016-06-11T13:11:33+00:00","createdAt":"2016-06-09T12:46:42+00:00","_links":{"self":{"title":"Pact","name":"Pact between billy (v1.0.0) and bobby","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0"},"pb:consumer":{"title":"Consumer","name":"billy","href":"%s/pacticipants/billy"},"pb:provider":{"title":"Provider","name":"bobby","href":"%s/pacticipants/bobby"},"pb:latest-pact-version":{"title":"Pact","name":"Latest version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/latest"},"pb:previous-distinct":{"title":"Pact","name":"Previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/previous-distinct"},"pb:diff-previous-distinct":{"title":"Diff","name":"Diff with previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/diff/previous-distinct"},"pb:pact-webhooks":{"title":"Webhooks for the pact between billy and bobby","href":"%s/webhooks/provider/bobby/consumer/billy"},"pb:tag-prod-version":{"title":"Tag this version as 'production'","href":"%s/pacticipants/billy/versions/1.0.0/tags/prod"},"pb:tag-version":{"title":"Tag version","href":"%s/pacticipants/billy/versions/1.0.0/tags/{tag}"},"curies":[{"name":"pb","href":"%s/doc/{rel}","templated":true}]}}`, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL)
+		fmt.Fprintf(w, `{"consumer":{"name":"billy"},"provider":{"name":"bobby"},"interactions":[{"description":"Some name for the test","provider_state":"Some state","request":{"method":"GET","path":"/foobar"},"response":{"status":200,"headers":{"Content-Type":"application/json"}}},{"description":"Some name for the test","provider_state":"Some state2","request":{"method":"GET","path":"/bazbat"},"response":{"status":200,"headers":{},"body":[[{"colour":"red","size":10,"tag":[["jumper","shirt"],["jumper","shirt"]]}]],"matchingRules":{"$.body":{"min":1},"$.body[*].*":{"match":"type"},"$.body[*]":{"min":1},"$.body[*][*].*":{"match":"type"},"$.body[*][*].colour":{"match":"regex","regex":"red|green|blue"},"$.body[*][*].size":{"match":"type"},"$.body[*][*].tag":{"min":2},"$.body[*][*].tag[*].*":{"match":"type"},"$.body[*][*].tag[*][0]":{"match":"type"},"$.body[*][*].tag[*][1]":{"match":"type"}}}}],"metadata":{"pactSpecificationVersion":"2.0.0"},"updatedAt":"2016-06-11T13:11:33+00:00","createdAt":"2016-06-09T12:46:42+00:00","_links":{"self":{"title":"Pact","name":"Pact between billy (v1.0.0) and bobby","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0"},"pb:consumer":{"title":"Consumer","name":"billy","href":"%s/pacticipants/billy"},"pb:provider":{"title":"Provider","name":"bobby","href":"%s/pacticipants/bobby"},"pb:latest-pact-version":{"title":"Pact","name":"Latest version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/latest"},"pb:previous-distinct":{"title":"Pact","name":"Previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/previous-distinct"},"pb:diff-previous-distinct":{"title":"Diff","name":"Diff with previous distinct version of this pact","href":"%s/pacts/provider/bobby/consumer/billy/version/1.0.0/diff/previous-distinct"},"pb:pact-webhooks":{"title":"Webhooks for the pact between billy and bobby","href":"%s/webhooks/provider/bobby/consumer/billy"},"pb:tag-prod-version":{"title":"Tag this version as 'production'","href":"%s/pacticipants/billy/versions/1.0.0/tags/prod"},"pb:tag-version":{"title":"Tag version","href":"%s/pacticipants/billy/versions/1.0.0/tags/{tag}"},"pb:publish-verification-results":{"title":"Publish verification results","href":"%s/pacts/provider/bobby/consumer/billy/pact-version/1/verification-results"},"curies":[{"name":"pb","href":"%s/doc/{rel}","templated":true}]}}`, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL, server.URL)
+		w.Header().Add("Content-Type", "application/hal+json")
+	}))
+
+	mux.Handle("/pacts/provider/bobby/consumer/jessica/version/", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, `{"consumer":{"name":"jessica"},"provider":{"name":"bobby"},"interactions":[],"_links":{"pb:publish-verification-results":{"title":"Publish verification results","href":"%s/pacts/provider/bobby/consumer/jessica/pact-version/2/verification-results"}}}`, server.URL)
+		w.Header().Add("Content-Type", "application/hal+json")
+	}))
+
+	// Verification results and Provider version tags
+	verificationResults := authFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Add("Content-Type", "application/hal+json")
+		fmt.Fprint(w, `{}`)
+	})
+	mux.Handle("/pacts/provider/bobby/consumer/billy/pact-version/", verificationResults)
+	mux.Handle("/pacts/provider/bobby/consumer/jessica/pact-version/", verificationResults)
+	mux.Handle("/pacticipants/bobby/versions/", authFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
 		w.Header().Add("Content-Type", "application/hal+json")
+		fmt.Fprint(w, `{}`)
 	}))
 
 	return server
 }
+
+func TestBroker_brokerClient(t *testing.T) {
+	client, err := brokerClient("http://broker", "", "", "abcd", types.BrokerTransport{})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if client.Auth != (broker.BearerAuth{Token: "abcd"}) || client.HTTPClient != nil {
+		t.Fatalf("expected bearer authentication, got %+v", client)
+	}
+
+	client, err = brokerClient("http://broker", "foo", "bar", "", types.BrokerTransport{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if client.Auth != (broker.BasicAuth{Username: "foo", Password: "bar"}) || client.HTTPClient == nil {
+		t.Fatalf("expected basic authentication and a custom transport, got %+v", client)
+	}
+
+	if _, err = brokerClient("http://broker", "foo", "bar", "abcd", types.BrokerTransport{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if _, err = brokerClient("http://broker", "", "", "", types.BrokerTransport{CACertFile: "does-not-exist.pem"}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestBroker_downloadPacts(t *testing.T) {
+	s := setupMockBroker(true)
+	defer s.Close()
+
+	var pactURLs []string
+	var contents []string
+	verify := downloadPacts(context.Background(), func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		pactURLs = request.PactURLs
+		for _, file := range request.PactURLs {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal("Error:", err)
+			}
+			contents = append(contents, string(data))
+		}
+		return types.ProviderVerifierResponse{}, nil
+	})
+
+	_, err := verify(types.VerifyRequest{
+		PactURLs:       []string{s.URL + "/pacts/provider/bobby/consumer/billy/version/1.0.0"},
+		BrokerUsername: "foo",
+		BrokerPassword: "bar",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(pactURLs) != 1 || strings.HasPrefix(pactURLs[0], "http") || !strings.Contains(contents[0], `"name":"billy"`) {
+		t.Fatalf("expected the pact to be downloaded, got %v", pactURLs)
+	}
+	if _, err = os.Stat(pactURLs[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected the downloaded pact to be removed")
+	}
+
+	_, err = verify(types.VerifyRequest{
+		PactURLs: []string{s.URL + "/pacts/provider/bobby/consumer/billy/version/1.0.0"},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
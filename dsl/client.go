@@ -137,6 +137,12 @@ func (p *PactClient) VerifyProvider(request types.VerifyRequest) (types.Provider
 	// Else, return an error, include stderr and stdout in both the error and message.
 	svc := p.verificationSvcManager.NewService(request.Args)
 	cmd := svc.Command()
+	if request.FilterDescription != "" {
+		cmd.Env = append(cmd.Env, "PACT_DESCRIPTION="+request.FilterDescription)
+	}
+	if request.FilterState != "" {
+		cmd.Env = append(cmd.Env, "PACT_PROVIDER_STATE="+request.FilterState)
+	}
 
 	stdOutPipe, err := cmd.StdoutPipe()
 	if err != nil {
@@ -99,6 +99,27 @@ func TestClient_VerifyProviderFailValidation(t *testing.T) {
 	}
 }
 
+func TestClient_VerifyProviderFailBrokerAuth(t *testing.T) {
+	client, _ := createClient(true)
+
+	req := types.VerifyRequest{
+		ProviderBaseURL: "http://localhost",
+		PactURLs:        []string{"foo.json"},
+		BrokerUsername:  "foo",
+		BrokerPassword:  "bar",
+		BrokerToken:     "abcd",
+	}
+	_, err := client.VerifyProvider(req)
+
+	if err == nil {
+		t.Fatal("Expected a error but got none")
+	}
+
+	if !strings.Contains(err.Error(), "BrokerToken cannot be used with BrokerUsername and BrokerPassword") {
+		t.Fatalf("Expected a proper error message but got '%s'", err.Error())
+	}
+}
+
 func TestClient_VerifyProviderFailExecution(t *testing.T) {
 	client, _ := createClient(false)
 
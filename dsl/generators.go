@@ -0,0 +1,270 @@
+package dsl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Generate returns a copy of the response with the values that have a
+// generator replaced by generated ones, e.g. a new random id or the current
+// date. Generators that are not supported leave the example value as is.
+func (r PactResponse) Generate() PactResponse {
+	if len(r.Generators) == 0 {
+		return r
+	}
+
+	response := r
+	response.Headers = make(map[string]string, len(r.Headers))
+	for key, value := range r.Headers {
+		response.Headers[key] = value
+	}
+	response.Body = copyJSON(r.Body)
+
+	for _, path := range sortedGeneratorKeys(r.Generators) {
+		generator := r.Generators[path]
+		tokens := parsePath(path)
+		if len(tokens) < 2 {
+			continue
+		}
+
+		switch tokens[1] {
+		case "status":
+			if v, ok := generate(generator).(int); ok {
+				response.Status = v
+			}
+		case "headers", "header":
+			if len(tokens) == 3 {
+				if v := generate(generator); v != nil {
+					response.Headers[tokens[2]] = fmt.Sprint(v)
+				}
+			}
+		case "body":
+			response.Body = generateBody(response.Body, tokens[2:], generator)
+		}
+	}
+
+	return response
+}
+
+// generateBody replaces the values at the path of a JSON body, which may
+// contain wildcards, with generated values.
+func generateBody(body interface{}, path []string, generator Generator) interface{} {
+	if len(path) == 0 {
+		if v := generate(generator); v != nil {
+			return v
+		}
+		return body
+	}
+
+	switch b := body.(type) {
+	case map[string]interface{}:
+		for key, value := range b {
+			if path[0] == "*" || path[0] == key {
+				b[key] = generateBody(value, path[1:], generator)
+			}
+		}
+	case []interface{}:
+		for i, value := range b {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				b[i] = generateBody(value, path[1:], generator)
+			}
+		}
+	}
+
+	return body
+}
+
+// generate creates a value for a generator, or returns nil if the type of
+// generator is not supported.
+func generate(g Generator) interface{} {
+	switch g.Type {
+	case "RandomInt":
+		min, max := 0, 2147483647
+		if g.Min != nil {
+			min = *g.Min
+		}
+		if g.Max != nil {
+			max = *g.Max
+		}
+		if max < min {
+			return min
+		}
+		return min + randomInt(max-min+1)
+	case "RandomDecimal":
+		digits := intOr(g.Digits, 10)
+		s := randomDigits(digits)
+		point := 1 + randomInt(digits-1)
+		v, _ := strconv.ParseFloat(s[:point]+"."+s[point:], 64)
+		return v
+	case "RandomHexadecimal":
+		return randomString(intOr(g.Digits, 10), "0123456789abcdef")
+	case "RandomString":
+		return randomString(intOr(g.Size, 20), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	case "RandomBoolean":
+		return randomInt(2) == 1
+	case "Uuid":
+		b := []byte(randomString(32, "0123456789abcdef"))
+		return fmt.Sprintf("%s-%s-%s-%s-%s", b[0:8], b[8:12], b[12:16], b[16:20], b[20:32])
+	case "Date":
+		return formatNow(formatOr(g.Format, "yyyy-MM-dd"))
+	case "Time":
+		return formatNow(formatOr(g.Format, "HH:mm:ss"))
+	case "DateTime", "Timestamp":
+		return formatNow(formatOr(g.Format, "yyyy-MM-dd'T'HH:mm:ss"))
+	case "Regex":
+		re, err := syntax.Parse(g.Regex, syntax.Perl)
+		if err != nil {
+			log.Printf("[WARN] generators: invalid regular expression '%s': %v", g.Regex, err)
+			return nil
+		}
+		var b bytes.Buffer
+		generateRegex(&b, re.Simplify())
+		return b.String()
+	}
+
+	log.Printf("[DEBUG] generators: generator type '%s' is not supported, using the example value", g.Type)
+	return nil
+}
+
+// maxRegexRepeat limits the repetitions generated for unbounded regular
+// expressions such as "a+".
+const maxRegexRepeat = 10
+
+// generateRegex writes a random string matching a regular expression.
+func generateRegex(b *bytes.Buffer, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		// Rune holds inclusive ranges of runes
+		var total int
+		for i := 0; i < len(re.Rune); i += 2 {
+			total += int(re.Rune[i+1]-re.Rune[i]) + 1
+		}
+		if total == 0 {
+			return
+		}
+		n := randomInt(total)
+		for i := 0; i < len(re.Rune); i += 2 {
+			size := int(re.Rune[i+1]-re.Rune[i]) + 1
+			if n < size {
+				b.WriteRune(re.Rune[i] + rune(n))
+				return
+			}
+			n -= size
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteString(randomString(1, "abcdefghijklmnopqrstuvwxyz"))
+	case syntax.OpCapture:
+		generateRegex(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			generateRegex(b, sub)
+		}
+	case syntax.OpAlternate:
+		generateRegex(b, re.Sub[randomInt(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + maxRegexRepeat
+		}
+		for i := min + randomInt(max-min+1); i > 0; i-- {
+			generateRegex(b, re.Sub[0])
+		}
+	}
+}
+
+// copyJSON returns a deep copy of a JSON value, so that generated values do
+// not change the example of an interaction.
+func copyJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return v
+		}
+		var c interface{}
+		json.Unmarshal(data, &c)
+		return c
+	}
+
+	return v
+}
+
+func sortedGeneratorKeys(g Generators) []string {
+	keys := make([]string, 0, len(g))
+	for k := range g {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func randomInt(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+// randomDigits returns a string of random digits that does not start with 0.
+func randomDigits(n int) string {
+	if n < 1 {
+		n = 1
+	}
+	return randomString(1, "123456789") + randomString(n-1, "0123456789")
+}
+
+func randomString(n int, alphabet string) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[randomInt(len(alphabet))]
+	}
+	return string(b)
+}
+
+func intOr(v *int, fallback int) int {
+	if v == nil || *v < 1 {
+		return fallback
+	}
+	return *v
+}
+
+// formatNow formats the current time with a Java style format, or returns
+// nil if the format is not supported.
+func formatNow(format string) interface{} {
+	layout, err := javaDateLayout(format)
+	if err != nil {
+		log.Println("[WARN] generators:", err)
+		return nil
+	}
+	return time.Now().Format(layout)
+}
+
+func formatOr(format string, fallback string) string {
+	if format == "" {
+		return fallback
+	}
+	return format
+}
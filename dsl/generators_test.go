@@ -0,0 +1,110 @@
+package dsl
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestPactResponse_Generate(t *testing.T) {
+	response := PactResponse{
+		Status:  200,
+		Headers: map[string]string{"Location": "/users/1"},
+		Body: map[string]interface{}{
+			"id":    1,
+			"name":  "sally",
+			"items": []interface{}{map[string]interface{}{"id": 1}, map[string]interface{}{"id": 2}},
+		},
+		Generators: Generators{
+			"$.status":           {Type: "RandomInt", Min: intPtr(201), Max: intPtr(201)},
+			"$.headers.Location": {Type: "Regex", Regex: `/users/\d{3}`},
+			"$.body.id":          {Type: "RandomInt", Min: intPtr(100), Max: intPtr(200)},
+			"$.body.items[*].id": {Type: "Uuid"},
+			"$.body.name":        {Type: "ProviderState", Expression: "${name}"},
+		},
+	}
+
+	generated := response.Generate()
+
+	if generated.Status != 201 {
+		t.Fatalf("expected a generated status of 201, got %d", generated.Status)
+	}
+	if !regexp.MustCompile(`^/users/\d{3}$`).MatchString(generated.Headers["Location"]) {
+		t.Fatalf("expected a generated Location header, got '%s'", generated.Headers["Location"])
+	}
+
+	body := generated.Body.(map[string]interface{})
+	if id, ok := body["id"].(int); !ok || id < 100 || id > 200 {
+		t.Fatalf("expected a generated id between 100 and 200, got %v", body["id"])
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	for _, item := range body["items"].([]interface{}) {
+		if id, _ := item.(map[string]interface{})["id"].(string); !uuid.MatchString(id) {
+			t.Fatalf("expected a generated uuid, got %v", item)
+		}
+	}
+
+	// Unsupported generators keep the example
+	if body["name"] != "sally" {
+		t.Fatalf("expected the example name, got %v", body["name"])
+	}
+
+	// The example is unchanged
+	if response.Status != 200 || response.Headers["Location"] != "/users/1" || response.Body.(map[string]interface{})["id"] != 1 {
+		t.Fatalf("expected the example response to be unchanged, got %+v", response)
+	}
+}
+
+func TestPactResponse_GenerateWithoutGenerators(t *testing.T) {
+	response := PactResponse{Status: 200, Body: "hello"}
+	if generated := response.Generate(); generated.Status != 200 || generated.Body != "hello" {
+		t.Fatalf("expected the example response, got %+v", generated)
+	}
+}
+
+func Test_generate(t *testing.T) {
+	checks := map[string]struct {
+		generator Generator
+		matches   func(interface{}) bool
+	}{
+		"RandomDecimal": {Generator{Type: "RandomDecimal", Digits: intPtr(4)}, func(v interface{}) bool {
+			_, ok := v.(float64)
+			return ok
+		}},
+		"RandomHexadecimal": {Generator{Type: "RandomHexadecimal", Digits: intPtr(8)}, func(v interface{}) bool {
+			return regexp.MustCompile(`^[0-9a-f]{8}$`).MatchString(v.(string))
+		}},
+		"RandomString": {Generator{Type: "RandomString", Size: intPtr(5)}, func(v interface{}) bool {
+			return len(v.(string)) == 5
+		}},
+		"RandomBoolean": {Generator{Type: "RandomBoolean"}, func(v interface{}) bool {
+			_, ok := v.(bool)
+			return ok
+		}},
+		"Date": {Generator{Type: "Date", Format: "dd/MM/yyyy"}, func(v interface{}) bool {
+			return v == time.Now().Format("02/01/2006")
+		}},
+		"Time": {Generator{Type: "Time"}, func(v interface{}) bool {
+			_, err := time.Parse("15:04:05", v.(string))
+			return err == nil
+		}},
+		"DateTime": {Generator{Type: "DateTime"}, func(v interface{}) bool {
+			_, err := time.Parse("2006-01-02T15:04:05", v.(string))
+			return err == nil
+		}},
+		"Regex": {Generator{Type: "Regex", Regex: `^(red|green)-[a-z]+\.(png|jpg)?$`}, func(v interface{}) bool {
+			return regexp.MustCompile(`^(red|green)-[a-z]+\.(png|jpg)?$`).MatchString(v.(string))
+		}},
+		"Invalid Regex": {Generator{Type: "Regex", Regex: `(`}, func(v interface{}) bool {
+			return v == nil
+		}},
+	}
+
+	for name, check := range checks {
+		for i := 0; i < 10; i++ {
+			if v := generate(check.generator); !check.matches(v) {
+				t.Fatalf("%s: unexpected generated value %#v", name, v)
+			}
+		}
+	}
+}
@@ -18,11 +18,26 @@ type Interaction struct {
 
 	// Provider state to be written into the Pact file
 	State string `json:"providerState,omitempty"`
+
+	// Provider states, with any parameters, to be written into the Pact file.
+	// More than one state, or parameters, requires version 3 of the Pact
+	// Specification and the NativeMockServer.
+	States []State `json:"providerStates,omitempty"`
 }
 
-// Given specifies a provider state. Optional.
+// Given specifies a provider state. May be called more than once to specify
+// multiple provider states. Optional.
 func (i *Interaction) Given(state string) *Interaction {
+	return i.GivenWithParams(state, nil)
+}
+
+// GivenWithParams specifies a provider state, with parameters that are passed
+// to the Provider when setting up the state e.g. the ID of a record to seed.
+// May be called more than once to specify multiple provider states, of which
+// State is the last. Optional.
+func (i *Interaction) GivenWithParams(state string, params map[string]interface{}) *Interaction {
 	i.State = state
+	i.States = append(i.States, State{Name: state, Params: params})
 
 	return i
 }
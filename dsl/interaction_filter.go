@@ -0,0 +1,276 @@
+package dsl
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// interactionFilter selects the interactions to verify.
+type interactionFilter struct {
+	description string
+	state       string
+	consumers   []string
+
+	// descriptionRegexp and stateRegexp are the compiled filters, if given.
+	descriptionRegexp *regexp.Regexp
+	stateRegexp       *regexp.Regexp
+}
+
+// newInteractionFilter returns the filter of a request, defaulting to the
+// PACT_DESCRIPTION and PACT_PROVIDER_STATE environment variables. It is an
+// error if the description or provider state is not a valid regular
+// expression.
+func newInteractionFilter(request types.VerifyRequest) (interactionFilter, error) {
+	f := interactionFilter{
+		description: request.FilterDescription,
+		state:       request.FilterState,
+		consumers:   request.FilterConsumers,
+	}
+	if f.description == "" {
+		f.description = os.Getenv("PACT_DESCRIPTION")
+	}
+	if f.state == "" {
+		f.state = os.Getenv("PACT_PROVIDER_STATE")
+	}
+
+	var err error
+	if f.description != "" {
+		if f.descriptionRegexp, err = regexp.Compile(f.description); err != nil {
+			return f, fmt.Errorf("invalid description filter '%s': %v", f.description, err)
+		}
+	}
+	if f.state != "" {
+		if f.stateRegexp, err = regexp.Compile(f.state); err != nil {
+			return f, fmt.Errorf("invalid provider state filter '%s': %v", f.state, err)
+		}
+	}
+
+	return f, nil
+}
+
+// noMatches is the error when no interaction matches the filter, which is
+// most likely a mistake in the filter.
+func (f interactionFilter) noMatches() error {
+	var filters []string
+	if f.description != "" {
+		filters = append(filters, fmt.Sprintf("description '%s'", f.description))
+	}
+	if f.state != "" {
+		filters = append(filters, fmt.Sprintf("provider state '%s'", f.state))
+	}
+	if len(f.consumers) > 0 {
+		filters = append(filters, fmt.Sprintf("consumers %v", f.consumers))
+	}
+
+	return fmt.Errorf("no interactions to verify match the %s", strings.Join(filters, " and "))
+}
+
+// active is true if only some interactions are verified.
+func (f interactionFilter) active() bool {
+	return f.description != "" || f.state != "" || len(f.consumers) > 0
+}
+
+// matchesConsumer is true if the interactions with the consumer are verified.
+func (f interactionFilter) matchesConsumer(consumer string) bool {
+	if len(f.consumers) == 0 {
+		return true
+	}
+	for _, c := range f.consumers {
+		if c == consumer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches is true if the interaction of the pact is verified. The
+// description also matches by the name of its subtest, so that the -run
+// flag of go test can be used as the filter.
+func (f interactionFilter) matches(pact *PactFile, interaction *PactInteraction) bool {
+	if !f.matchesConsumer(pact.Consumer.Name) {
+		return false
+	}
+
+	if f.description != "" && !matchesFilter(f.description, f.descriptionRegexp, interaction.Description, subtestName(interaction.Description)) {
+		return false
+	}
+
+	if f.state != "" {
+		for _, state := range interaction.ProviderStates {
+			if matchesFilter(f.state, f.stateRegexp, state.Name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// matchesFilter is true if any of the values equals the filter, or matches
+// its regular expression.
+func matchesFilter(filter string, r *regexp.Regexp, values ...string) bool {
+	for _, value := range values {
+		if value == filter || r.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subtestName is the name of the subtest run for a description, with spaces
+// and unprintable characters rewritten as testing.T.Run does.
+func subtestName(description string) string {
+	var b bytes.Buffer
+	for _, r := range description {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		case !strconv.IsPrint(r):
+			s := strconv.QuoteRune(r)
+			b.WriteString(s[1 : len(s)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// runFilter returns the pattern of the -run flag of go test that selects the
+// subtests of the test, if any e.g. "^A_request_for_billy$" when running
+// go test -run '^TestProvider$/^A_request_for_billy$'.
+func runFilter(t *testing.T) string {
+	f := flag.Lookup("test.run")
+	if f == nil || f.Value.String() == "" {
+		return ""
+	}
+
+	parts := splitRunPattern(f.Value.String())
+	depth := len(strings.Split(t.Name(), "/"))
+	if len(parts) <= depth {
+		return ""
+	}
+
+	return parts[depth]
+}
+
+// splitRunPattern splits the pattern of the -run flag into the patterns of
+// each level of subtests, ignoring slashes in brackets and parentheses.
+func splitRunPattern(pattern string) []string {
+	var parts []string
+	var b bytes.Buffer
+	class, depth := false, 0
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteByte(c)
+			i++
+			c = pattern[i]
+		case class:
+			class = c != ']'
+		case c == '[':
+			class = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '/' && depth == 0:
+			parts = append(parts, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	return append(parts, b.String())
+}
+
+// filterPactURLs prepares the filter of a request for the Ruby verifier,
+// which can not filter the pacts by consumer, and only matches the exact
+// description and provider state of an interaction. The pacts of other
+// consumers are removed, and the description and provider state filters are
+// resolved to the single description and provider state they match.
+func filterPactURLs(ctx context.Context, request *types.VerifyRequest) error {
+	f, err := newInteractionFilter(*request)
+	if err != nil || !f.active() {
+		return err
+	}
+
+	var pactURLs []string
+	descriptions := make(map[string]bool)
+	states := make(map[string]bool)
+	for _, pactURL := range request.PactURLs {
+		pact, err := loadPactFile(ctx, pactURL, *request, nil)
+		if err != nil {
+			return err
+		}
+		if !f.matchesConsumer(pact.Consumer.Name) {
+			log.Printf("[DEBUG] not verifying the pact with %s: %s", pact.Consumer.Name, pactURL)
+			continue
+		}
+		pactURLs = append(pactURLs, pactURL)
+
+		for _, interaction := range pact.Interactions {
+			if !f.matches(pact, interaction) {
+				continue
+			}
+			descriptions[interaction.Description] = true
+			for _, state := range interaction.ProviderStates {
+				if f.state != "" && matchesFilter(f.state, f.stateRegexp, state.Name) {
+					states[state.Name] = true
+				}
+			}
+		}
+	}
+	if len(pactURLs) == 0 && len(f.consumers) > 0 {
+		return fmt.Errorf("no pacts to verify with the consumers %v", f.consumers)
+	}
+	if len(descriptions) == 0 {
+		return f.noMatches()
+	}
+	request.PactURLs = pactURLs
+
+	if f.description != "" {
+		if request.FilterDescription, err = exactFilter("description", f.description, descriptions); err != nil {
+			return err
+		}
+	}
+	if f.state != "" {
+		if request.FilterState, err = exactFilter("provider state", f.state, states); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exactFilter is the only value matched by a filter, as the Ruby verifier
+// can not match several.
+func exactFilter(name string, filter string, values map[string]bool) (string, error) {
+	var matched []string
+	for value := range values {
+		matched = append(matched, value)
+	}
+	if len(matched) == 1 {
+		return matched[0], nil
+	}
+
+	sort.Strings(matched)
+	return "", fmt.Errorf("the Ruby verifier can only verify the interactions of a single %s, but '%s' matches '%s'", name, filter, strings.Join(matched, "', '"))
+}
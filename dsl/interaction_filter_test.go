@@ -0,0 +1,169 @@
+package dsl
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestInteractionFilter_matches(t *testing.T) {
+	pact := &PactFile{Consumer: PactName{Name: "billy"}}
+	interaction := &PactInteraction{
+		Description:    "A request for billy (v2)",
+		ProviderStates: []State{{Name: "User billy exists"}},
+	}
+
+	checks := map[string]struct {
+		request types.VerifyRequest
+		matches bool
+	}{
+		"no filter":             {types.VerifyRequest{}, true},
+		"description":           {types.VerifyRequest{FilterDescription: "A request for billy (v2)"}, true},
+		"description regex":     {types.VerifyRequest{FilterDescription: "^A request for"}, true},
+		"subtest name":          {types.VerifyRequest{FilterDescription: `^A_request_for_billy_\(v2\)$`}, true},
+		"other description":     {types.VerifyRequest{FilterDescription: "sally"}, false},
+		"state":                 {types.VerifyRequest{FilterState: "User billy exists"}, true},
+		"other state":           {types.VerifyRequest{FilterState: "User sally exists"}, false},
+		"consumer":              {types.VerifyRequest{FilterConsumers: []string{"sally", "billy"}}, true},
+		"other consumer":        {types.VerifyRequest{FilterConsumers: []string{"sally"}}, false},
+		"description and state": {types.VerifyRequest{FilterDescription: "billy", FilterState: "sally"}, false},
+	}
+
+	for name, check := range checks {
+		f, err := newInteractionFilter(check.request)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if matches := f.matches(pact, interaction); matches != check.matches {
+			t.Fatalf("%s: expected matches to be %v", name, check.matches)
+		}
+	}
+}
+
+func TestInteractionFilter_invalid(t *testing.T) {
+	for _, request := range []types.VerifyRequest{{FilterDescription: "billy("}, {FilterState: "User [billy exists"}} {
+		if _, err := newInteractionFilter(request); err == nil {
+			t.Fatalf("Expected error but got none")
+		}
+	}
+
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{Handler: http.NotFoundHandler()}
+	_, err := verifier.VerifyProvider(types.VerifyRequest{PactURLs: []string{file}, FilterDescription: "billy("})
+	if err == nil || !strings.Contains(err.Error(), "invalid description filter 'billy('") {
+		t.Fatalf("expected the regular expression error, got %v", err)
+	}
+
+	request := types.VerifyRequest{PactURLs: []string{file}, FilterState: "User [billy exists"}
+	if err = filterPactURLs(context.Background(), &request); err == nil || !strings.Contains(err.Error(), "invalid provider state filter") {
+		t.Fatalf("expected the regular expression error, got %v", err)
+	}
+}
+
+func TestInteractionFilter_environment(t *testing.T) {
+	os.Setenv("PACT_DESCRIPTION", "A request for billy")
+	os.Setenv("PACT_PROVIDER_STATE", "User billy exists")
+	defer os.Unsetenv("PACT_DESCRIPTION")
+	defer os.Unsetenv("PACT_PROVIDER_STATE")
+
+	f, _ := newInteractionFilter(types.VerifyRequest{})
+	if f.description != "A request for billy" || f.state != "User billy exists" || !f.active() {
+		t.Fatalf("expected the filter of the environment, got %+v", f)
+	}
+
+	if f, _ = newInteractionFilter(types.VerifyRequest{FilterDescription: "sally"}); f.description != "sally" {
+		t.Fatalf("expected the filter of the request, got %+v", f)
+	}
+}
+
+func Test_subtestName(t *testing.T) {
+	if name := subtestName("A request\tfor billy\x00"); name != `A_request_for_billy\x00` {
+		t.Fatalf("expected the subtest name, got %s", name)
+	}
+}
+
+func Test_splitRunPattern(t *testing.T) {
+	checks := map[string][]string{
+		"TestProvider":                       {"TestProvider"},
+		"^TestProvider$/^A_request$":         {"^TestProvider$", "^A_request$"},
+		"TestProvider/a[/]b/(c/d)":           {"TestProvider", "a[/]b", "(c/d)"},
+		`TestProvider/a\/b`:                  {"TestProvider", `a\/b`},
+		"TestProvider/A_request/Nested/Test": {"TestProvider", "A_request", "Nested", "Test"},
+	}
+
+	for pattern, expected := range checks {
+		if parts := splitRunPattern(pattern); !reflect.DeepEqual(parts, expected) {
+			t.Fatalf("%s: expected %v, got %v", pattern, expected, parts)
+		}
+	}
+}
+
+func TestNativeVerifier_VerifyProviderFilter(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	checks := map[string]struct {
+		request  types.VerifyRequest
+		examples int
+	}{
+		"description":    {types.VerifyRequest{FilterDescription: "^A_request_for_billy$"}, 1},
+		"state":          {types.VerifyRequest{FilterState: "User sally exists"}, 0},
+		"other consumer": {types.VerifyRequest{FilterConsumers: []string{"sally"}}, 0},
+	}
+
+	for name, check := range checks {
+		check.request.ProviderBaseURL = provider.URL
+		check.request.PactURLs = []string{file}
+		res, _ := (&NativeVerifier{}).VerifyProvider(check.request)
+		if len(res.Examples) != check.examples {
+			t.Fatalf("%s: expected %d examples, got %+v", name, check.examples, res.Examples)
+		}
+	}
+}
+
+func Test_filterPactURLs(t *testing.T) {
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	request := types.VerifyRequest{PactURLs: []string{file}, FilterConsumers: []string{"billy"}}
+	if err := filterPactURLs(context.Background(), &request); err != nil || len(request.PactURLs) != 1 {
+		t.Fatalf("expected the pact with billy to be verified, got %v %v", request.PactURLs, err)
+	}
+
+	request.FilterConsumers = []string{"sally"}
+	if err := filterPactURLs(context.Background(), &request); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	// The Ruby verifier is given the exact description and provider state
+	request = types.VerifyRequest{PactURLs: []string{file}, FilterDescription: "^A_request_for_billy$", FilterState: "billy"}
+	if err := filterPactURLs(context.Background(), &request); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if request.FilterDescription != "A request for billy" || request.FilterState != "User billy exists" {
+		t.Fatalf("expected the filters to be resolved, got '%s' and '%s'", request.FilterDescription, request.FilterState)
+	}
+
+	request = types.VerifyRequest{PactURLs: []string{file}, FilterDescription: "sally"}
+	if err := filterPactURLs(context.Background(), &request); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	// A filter can only match a single description
+	i, _ := newPactInteraction(mustMarshal(t, userInteraction().UponReceiving("A request for billy's friends")))
+	WritePactFile(&PactFile{Consumer: PactName{Name: "billy"}, Provider: PactName{Name: "bobby"}, Interactions: []*PactInteraction{i}}, filepath.Dir(file), "update")
+	request = types.VerifyRequest{PactURLs: []string{file}, FilterDescription: "^A request for billy"}
+	if err := filterPactURLs(context.Background(), &request); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
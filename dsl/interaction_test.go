@@ -124,3 +124,22 @@ func TestInteraction_isStringLikeObject(t *testing.T) {
 		}
 	}
 }
+
+func TestInteraction_GivenWithParams(t *testing.T) {
+	i := (&Interaction{}).
+		GivenWithParams("User exists", map[string]interface{}{"id": 1}).
+		Given("User is an admin").
+		UponReceiving("Some name for the test")
+
+	if i.State != "User is an admin" {
+		t.Fatalf("Expected 'User is an admin' but got '%s'", i.State)
+	}
+
+	want := []State{
+		{Name: "User exists", Params: map[string]interface{}{"id": 1}},
+		{Name: "User is an admin"},
+	}
+	if !reflect.DeepEqual(i.States, want) {
+		t.Fatalf("Expected %v but got %v", want, i.States)
+	}
+}
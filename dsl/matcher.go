@@ -1,6 +1,7 @@
 package dsl
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -91,8 +92,13 @@ func Identifier() Matcher {
 	return Like(42)
 }
 
-// Integer defines a matcher that accepts ints. Identical to Identifier.
-var Integer = Identifier
+// Integer defines a matcher that accepts integers. Unlike Identifier, a
+// decimal value such as 42.5 will not match.
+// Requires version 3 of the Pact Specification and the NativeMockServer, or
+// is treated as Identifier otherwise.
+func Integer() Matcher {
+	return v3Matcher("integer", 42)
+}
 
 // IPAddress defines a matcher that accepts valid IPv4 addresses.
 func IPAddress() Matcher {
@@ -107,29 +113,238 @@ func IPv6Address() Matcher {
 	return Regex("::ffff:192.0.2.128", ipAddress)
 }
 
-// Decimal defines a matcher that accepts any decimal value.
+// Decimal defines a matcher that accepts decimal values. An integer value
+// such as 42 will not match.
+// Requires version 3 of the Pact Specification and the NativeMockServer, or
+// matches any number otherwise.
 func Decimal() Matcher {
-	return Like(42.0)
+	return v3Matcher("decimal", 42.0)
 }
 
 // Timestamp matches a pattern corresponding to the ISO_DATETIME_FORMAT, which
 // is "yyyy-MM-dd'T'HH:mm:ss". The current date and time is used as the eaxmple.
-func Timestamp() Matcher {
+//
+// Optionally, a Java style format (e.g. "yyyy-MM-dd'T'HH:mm:ssXXX") may be
+// given to match timestamps of that format, which requires version 3 of the
+// Pact Specification.
+func Timestamp(format ...string) Matcher {
+	if len(format) > 0 {
+		return dateTimeMatcher("timestamp", format[0])
+	}
 	return Regex(timeExample.Format(time.RFC3339), timestamp)
 }
 
 // Date matches a pattern corresponding to the ISO_DATE_FORMAT, which
 // is "yyyy-MM-dd". The current date is used as the eaxmple.
-func Date() Matcher {
+//
+// Optionally, a Java style format (e.g. "dd/MM/yyyy") may be given to match
+// dates of that format, which requires version 3 of the Pact Specification.
+func Date(format ...string) Matcher {
+	if len(format) > 0 {
+		return dateTimeMatcher("date", format[0])
+	}
 	return Regex(timeExample.Format("2006-01-02"), date)
 }
 
 // Time matches a pattern corresponding to the ISO_DATE_FORMAT, which
 // is "'T'HH:mm:ss". The current tem is used as the eaxmple.
-func Time() Matcher {
+//
+// Optionally, a Java style format (e.g. "HH:mm") may be given to match times
+// of that format, which requires version 3 of the Pact Specification.
+func Time(format ...string) Matcher {
+	if len(format) > 0 {
+		return dateTimeMatcher("time", format[0])
+	}
 	return Regex(timeExample.Format("T15:04:05"), timeRegex)
 }
 
+// dateTimeMatcher matches dates and times with the given Java style format,
+// using the example time formatted accordingly as the example.
+func dateTimeMatcher(matcherType string, format string) Matcher {
+	layout, err := javaDateLayout(format)
+	if err != nil {
+		panic(fmt.Sprintf("%s matcher: %v", matcherType, err))
+	}
+	m := v3Matcher(matcherType, timeExample.Format(layout))
+	m["format"] = format
+
+	return m
+}
+
+// MinLike specifies that a given element in a JSON body can be repeated, with
+// at least "min" elements. Equivalent to EachLike.
+// Requires version 3 of the Pact Specification.
+func MinLike(content interface{}, min int) Matcher {
+	m := v3Matcher("type", repeat(content, min))
+	m["min"] = min
+
+	return m
+}
+
+// MaxLike specifies that a given element in a JSON body can be repeated, with
+// at most "max" elements.
+// Requires version 3 of the Pact Specification.
+func MaxLike(content interface{}, max int) Matcher {
+	m := v3Matcher("type", repeat(content, 1))
+	m["max"] = max
+
+	return m
+}
+
+// MinMaxLike specifies that a given element in a JSON body can be repeated,
+// with at least "min" and at most "max" elements.
+// Requires version 3 of the Pact Specification.
+func MinMaxLike(content interface{}, min int, max int) Matcher {
+	m := v3Matcher("type", repeat(content, min))
+	m["min"] = min
+	m["max"] = max
+
+	return m
+}
+
+// Includes specifies that a string must contain the given value.
+// Requires version 3 of the Pact Specification.
+func Includes(content string) Matcher {
+	return v3Matcher("include", content)
+}
+
+// Null specifies that a value must be null.
+// Requires version 3 of the Pact Specification.
+func Null() Matcher {
+	return v3Matcher("null", nil)
+}
+
+// Equality specifies that a value must be equal to the given content,
+// resetting any type matching inherited from a parent matcher.
+// Requires version 3 of the Pact Specification.
+func Equality(content interface{}) Matcher {
+	return v3Matcher("equality", content)
+}
+
+// Values specifies that the keys of a map are not important, and that each
+// value should match the given content.
+// Requires version 3 of the Pact Specification.
+func Values(content interface{}) Matcher {
+	return v3Matcher("values", content)
+}
+
+// v3Matcher creates a matcher in the format understood by the native Pact
+// implementation, as used for version 3 of the Pact Specification.
+func v3Matcher(matcherType string, value interface{}) Matcher {
+	return Matcher{
+		"pact:matcher:type": matcherType,
+		"value":             value,
+	}
+}
+
+// repeat creates a slice containing the content the given number of times,
+// with at least one element.
+func repeat(content interface{}, count int) []interface{} {
+	if count < 1 {
+		count = 1
+	}
+
+	items := make([]interface{}, count)
+	for i := range items {
+		items[i] = content
+	}
+
+	return items
+}
+
+// javaLayoutTokens maps Java SimpleDateFormat patterns to Go layouts,
+// longest patterns first.
+var javaLayoutTokens = []struct {
+	java   string
+	layout string
+}{
+	{"yyyy", "2006"}, {"yy", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"dd", "02"}, {"d", "2"},
+	{"EEEE", "Monday"}, {"EEE", "Mon"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"SSSSSS", "000000"}, {"SSS", "000"}, {"SS", "00"}, {"S", "0"},
+	{"a", "PM"},
+	{"XXX", "Z07:00"}, {"XX", "Z0700"}, {"X", "Z07"},
+	{"ZZ", "-07:00"}, {"Z", "-0700"}, {"z", "MST"},
+}
+
+// goLayoutLiteral matches literal text that Go would read as part of a time
+// layout, which unlike Java formats can not be quoted.
+var goLayoutLiteral = regexp.MustCompile(`[0-9]|Jan|Mon|MST|PM|pm`)
+
+// javaDateLayout converts a Java SimpleDateFormat pattern, as used in pact
+// files, into the equivalent Go time layout. Literal text that Go would read
+// as a layout, such as digits, can not be converted.
+func javaDateLayout(format string) (string, error) {
+	var b bytes.Buffer
+	var literal bytes.Buffer
+
+	for i := 0; i < len(format); {
+		// Quoted literal text e.g. 'T', with '' as an escaped quote
+		if format[i] == '\'' {
+			i++
+			if i < len(format) && format[i] == '\'' {
+				literal.WriteByte('\'')
+				i++
+				continue
+			}
+			for i < len(format) {
+				if format[i] == '\'' {
+					if i+1 < len(format) && format[i+1] == '\'' {
+						literal.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				literal.WriteByte(format[i])
+				i++
+			}
+			continue
+		}
+
+		found := false
+		for _, token := range javaLayoutTokens {
+			if strings.HasPrefix(format[i:], token.java) {
+				if err := writeLayoutLiteral(&b, &literal, format); err != nil {
+					return "", err
+				}
+				b.WriteString(token.layout)
+				i += len(token.java)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			literal.WriteByte(format[i])
+			i++
+		}
+	}
+
+	if err := writeLayoutLiteral(&b, &literal, format); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// writeLayoutLiteral moves the literal text of a Java format into the Go
+// layout, checking Go will not read it as part of the layout.
+func writeLayoutLiteral(b *bytes.Buffer, literal *bytes.Buffer, format string) error {
+	if token := goLayoutLiteral.FindString(literal.String()); token != "" {
+		return fmt.Errorf("the literal text '%s' of the date format '%s' is not supported, as '%s' is part of a Go time layout", literal.String(), format, token)
+	}
+	b.Write(literal.Bytes())
+	literal.Reset()
+
+	return nil
+}
+
 // UUID defines a matcher that accepts UUIDs. Produces a v4 UUID as the example.
 func UUID() Matcher {
 	return Regex("fc763eba-0905-41c5-a27f-3934ab26786c", uuid)
@@ -187,6 +402,10 @@ func (m Matcher) isMatcher() {}
 // GetValue returns the raw generated value for the matcher
 // without any of the matching detail context
 func (m Matcher) GetValue() interface{} {
+	if _, ok := m["pact:matcher:type"]; ok {
+		return m["value"]
+	}
+
 	switch m["json_class"] {
 	default:
 		return nil
@@ -348,3 +567,72 @@ func pluckParams(srcType reflect.Type, pactTag string) params {
 func triggerInvalidPactTagPanic(tag string, err error) {
 	panic(fmt.Sprintf("match: encountered invalid pact tag %q . . . parsing failed with error: %v", tag, err))
 }
+
+// rubyContent serialises the given content and converts any version 3
+// matchers within it with rubyMatchers.
+func rubyContent(content interface{}) (interface{}, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return rubyMatchers(value), nil
+}
+
+// rubyMatchers converts any version 3 matchers within a serialised
+// interaction into the nearest Ruby matcher understood by the Ruby Mock
+// Service, which only supports version 2 of the Pact Specification.
+func rubyMatchers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		matcherType, ok := v["pact:matcher:type"].(string)
+		if !ok {
+			result := make(map[string]interface{}, len(v))
+			for key, item := range v {
+				result[key] = rubyMatchers(item)
+			}
+			return result
+		}
+
+		content := rubyMatchers(v["value"])
+		switch matcherType {
+		case "type":
+			items, isArray := content.([]interface{})
+			_, hasMin := v["min"]
+			_, hasMax := v["max"]
+			if !isArray || len(items) == 0 || !(hasMin || hasMax) {
+				return Like(content)
+			}
+			if hasMax {
+				log.Println("[WARN] matcher: the maximum array length is not supported by the Ruby Mock Service and will be ignored")
+			}
+			min := 1
+			if m, ok := v["min"].(float64); ok {
+				min = int(m)
+			}
+			return EachLike(items[0], min)
+		case "regex":
+			return Term(fmt.Sprint(content), fmt.Sprint(v["regex"]))
+		case "include":
+			return Term(fmt.Sprint(content), regexp.QuoteMeta(fmt.Sprint(content)))
+		case "null", "equality":
+			return content
+		default:
+			log.Printf("[WARN] matcher: '%s' matchers are not supported by the Ruby Mock Service, using a type match instead", matcherType)
+			return Like(content)
+		}
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = rubyMatchers(item)
+		}
+		return result
+	}
+
+	return value
+}
@@ -349,6 +349,15 @@ func formatJSON(object interface{}) interface{} {
 func (m Matcher) getValue() interface{} {
 	mString := objectToString(m)
 
+	// try v3
+	if _, ok := m["pact:matcher:type"]; ok {
+		var v3Value struct {
+			Value interface{} `json:"value"`
+		}
+		json.Unmarshal([]byte(mString), &v3Value)
+		return v3Value.Value
+	}
+
 	// try like
 	likeValue := &like{}
 	err := json.Unmarshal([]byte(mString), likeValue)
@@ -947,3 +956,94 @@ func Test_pluckParams(t *testing.T) {
 		})
 	}
 }
+
+func TestMatcher_V3Matchers(t *testing.T) {
+	cases := map[string]struct {
+		matcher Matcher
+		want    string
+	}{
+		"MinLike":    {MinLike("a", 2), `{"min":2,"pact:matcher:type":"type","value":["a","a"]}`},
+		"MaxLike":    {MaxLike("a", 5), `{"max":5,"pact:matcher:type":"type","value":["a"]}`},
+		"MinMaxLike": {MinMaxLike("a", 2, 5), `{"max":5,"min":2,"pact:matcher:type":"type","value":["a","a"]}`},
+		"Includes":   {Includes("bill"), `{"pact:matcher:type":"include","value":"bill"}`},
+		"Null":       {Null(), `{"pact:matcher:type":"null","value":null}`},
+		"Equality":   {Equality("billy"), `{"pact:matcher:type":"equality","value":"billy"}`},
+		"Values":     {Values(map[string]int{"a": 1}), `{"pact:matcher:type":"values","value":{"a":1}}`},
+		"Integer":    {Integer(), `{"pact:matcher:type":"integer","value":42}`},
+		"Decimal":    {Decimal(), `{"pact:matcher:type":"decimal","value":42}`},
+		"Date":       {Date("dd/MM/yyyy"), `{"format":"dd/MM/yyyy","pact:matcher:type":"date","value":"01/02/2000"}`},
+		"Time":       {Time("HH:mm"), `{"format":"HH:mm","pact:matcher:type":"time","value":"12:30"}`},
+		"Timestamp":  {Timestamp("yyyy-MM-dd'T'HH:mm:ssXXX"), `{"format":"yyyy-MM-dd'T'HH:mm:ssXXX","pact:matcher:type":"timestamp","value":"2000-02-01T12:30:00Z"}`},
+	}
+
+	for name, tc := range cases {
+		if got := objectToString(tc.matcher); got != tc.want {
+			t.Fatalf("%s: want %s, got %s", name, tc.want, got)
+		}
+	}
+
+	if v := Equality("billy").GetValue(); v != "billy" {
+		t.Fatalf("want 'billy', got %v", v)
+	}
+}
+
+func TestMatcher_javaDateLayout(t *testing.T) {
+	cases := map[string]string{
+		"yyyy-MM-dd":                    "2006-01-02",
+		"dd/MM/yy":                      "02/01/06",
+		"HH:mm:ss.SSS":                  "15:04:05.000",
+		"yyyy-MM-dd'T'HH:mm:ssXXX":      "2006-01-02T15:04:05Z07:00",
+		"EEE, d MMM yyyy HH:mm:ss Z":    "Mon, 2 Jan 2006 15:04:05 -0700",
+		"h:mm a 'o''clock'":             "3:04 PM o'clock",
+		"MMMM d, yyyy":                  "January 2, 2006",
+		"yyyy-MM-dd'T'HH:mm:ss.SSSSSSZ": "2006-01-02T15:04:05.000000-0700",
+	}
+
+	for format, want := range cases {
+		if got, err := javaDateLayout(format); err != nil || got != want {
+			t.Fatalf("javaDateLayout(%q): want %q, got %q (%v)", format, want, got, err)
+		}
+	}
+
+	// Go would read these literals as part of the layout
+	for _, format := range []string{"yyyy-MM-dd'T1'", "yyyy 2 MM", "'Mon' dd", "HH 'PM'"} {
+		if _, err := javaDateLayout(format); err == nil {
+			t.Fatalf("javaDateLayout(%q): Expected error but got none", format)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected an unsupported date format to panic")
+		}
+	}()
+	Date("dd/MM/yyyy 'at 1'")
+}
+
+func TestMatcher_rubyMatchers(t *testing.T) {
+	var body interface{}
+	json.Unmarshal([]byte(objectToString(map[string]interface{}{
+		"id":       Integer(),
+		"items":    MinMaxLike(map[string]interface{}{"name": Includes("bill")}, 2, 5),
+		"deleted":  Null(),
+		"name":     Equality("billy"),
+		"created":  Date("dd/MM/yyyy"),
+		"term":     Term("1", "\\d"),
+		"unchaged": Like("a"),
+	})), &body)
+
+	got := objectToString(rubyMatchers(body))
+	want := objectToString(map[string]interface{}{
+		"id":       Like(42),
+		"items":    EachLike(map[string]interface{}{"name": Term("bill", "bill")}, 2),
+		"deleted":  nil,
+		"name":     "billy",
+		"created":  Like("01/02/2000"),
+		"term":     Term("1", "\\d"),
+		"unchaged": Like("a"),
+	})
+
+	if got != want {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+}
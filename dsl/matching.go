@@ -0,0 +1,625 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MatchingRule is a single rule used to compare an actual value against the
+// example recorded in an interaction, as found in the "matchingRules"
+// section of a pact file.
+type MatchingRule struct {
+	// Match is the type of match to perform e.g. "type" or "regex".
+	Match string `json:"match,omitempty"`
+
+	// Regex is the regular expression used by the "regex" match.
+	Regex string `json:"regex,omitempty"`
+
+	// Min is the minimum length of an array.
+	Min *int `json:"min,omitempty"`
+
+	// Max is the maximum length of an array.
+	Max *int `json:"max,omitempty"`
+
+	// Value is the string an "include" match must contain.
+	Value string `json:"value,omitempty"`
+
+	// Format is the Java style format of a "date", "time" or "timestamp"
+	// match e.g. "yyyy-MM-dd".
+	Format string `json:"format,omitempty"`
+}
+
+// MatchingRules maps a v2 style path expression (e.g. "$.body.name",
+// "$.headers.Content-Type" or "$.query.id") to the rules for that path.
+type MatchingRules map[string][]MatchingRule
+
+// add registers a rule for the given path expression.
+func (m MatchingRules) add(path string, rule MatchingRule) {
+	m[path] = append(m[path], rule)
+}
+
+// resolve finds the rules that apply to the given path. The most specific
+// path expression wins, and rules cascade from a parent to all of its
+// children, as per the Pact specification.
+func (m MatchingRules) resolve(path []string) []MatchingRule {
+	var best []MatchingRule
+	bestWeight := 0
+
+	for _, expression := range sortedRuleKeys(m) {
+		weight := pathWeight(parsePath(expression), path)
+		if weight > bestWeight {
+			best = m[expression]
+			bestWeight = weight
+		}
+	}
+
+	return best
+}
+
+// sortedRuleKeys returns the paths of the rules in a stable order.
+func sortedRuleKeys(m MatchingRules) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// pathWeight calculates how well a path expression matches a concrete path.
+// Exact tokens are weighted above wildcards, and a zero weight indicates the
+// expression does not apply to the path at all.
+func pathWeight(expression []string, path []string) int {
+	if len(expression) == 0 || len(expression) > len(path) {
+		return 0
+	}
+
+	weight := 1
+	for i, token := range expression {
+		switch {
+		case token == path[i]:
+			weight *= 2
+		case token == "*":
+			weight *= 1
+		case i == 2 && path[1] == "headers" && strings.EqualFold(token, path[i]):
+			weight *= 2
+		default:
+			return 0
+		}
+	}
+
+	return weight
+}
+
+var pathTokenRegex = regexp.MustCompile(`\['([^']*)'\]|\[([^\]]*)\]|\.([^.\[]*)`)
+
+// parsePath splits a path expression such as "$.body.items[*].name" into
+// its tokens i.e. ["$", "body", "items", "*", "name"].
+func parsePath(expression string) []string {
+	if !strings.HasPrefix(expression, "$") {
+		return nil
+	}
+
+	tokens := []string{"$"}
+	for _, m := range pathTokenRegex.FindAllStringSubmatch(expression[1:], -1) {
+		switch {
+		case m[1] != "":
+			tokens = append(tokens, m[1])
+		case m[2] != "":
+			tokens = append(tokens, m[2])
+		default:
+			tokens = append(tokens, m[3])
+		}
+	}
+
+	return tokens
+}
+
+var simplePathToken = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// formatPath renders path tokens back into a path expression.
+func formatPath(path []string) string {
+	var b bytes.Buffer
+	for i, token := range path {
+		switch {
+		case i == 0:
+			b.WriteString(token)
+		case token == "*" || isIndex(token):
+			fmt.Fprintf(&b, "[%s]", token)
+		case simplePathToken.MatchString(token):
+			b.WriteString("." + token)
+		default:
+			fmt.Fprintf(&b, "['%s']", token)
+		}
+	}
+
+	return b.String()
+}
+
+func isIndex(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+func appendPath(path []string, token string) []string {
+	p := make([]string, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, token)
+}
+
+// Mismatch is a single difference found when comparing an actual HTTP
+// request or response to what was expected by an interaction.
+type Mismatch struct {
+	// Type is the part of the request or response that differs, one of
+	// "method", "path", "query", "header", "body" or "status".
+	Type string `json:"type"`
+
+	// Path is the location of the mismatch e.g. "$.body.name".
+	Path string `json:"path,omitempty"`
+
+	// Expected is the expected value.
+	Expected interface{} `json:"expected,omitempty"`
+
+	// Actual is the value that was received.
+	Actual interface{} `json:"actual,omitempty"`
+
+	// Message is a human readable description of the mismatch.
+	Message string `json:"mismatch"`
+}
+
+func (m Mismatch) String() string {
+	return m.Message
+}
+
+// OnlyMismatches checks that all differences are of the given types, e.g. to
+// only report the differences with interactions for the same method and path.
+func OnlyMismatches(mismatches []Mismatch, types ...string) bool {
+	for _, mismatch := range mismatches {
+		found := false
+		for _, t := range types {
+			if mismatch.Type == t {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// comparison holds the context of a single request or response comparison.
+type comparison struct {
+	rules MatchingRules
+
+	// allowUnexpectedKeys is true for responses, where the provider may
+	// return more than the consumer asked for.
+	allowUnexpectedKeys bool
+
+	mismatches []Mismatch
+}
+
+func (c *comparison) mismatch(kind string, path []string, expected, actual interface{}, format string, args ...interface{}) {
+	c.mismatches = append(c.mismatches, Mismatch{
+		Type:     kind,
+		Path:     formatPath(path),
+		Expected: expected,
+		Actual:   actual,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// compare recursively compares an actual value with the expected example,
+// applying any matching rules found along the way.
+func (c *comparison) compare(kind string, path []string, expected, actual interface{}) {
+	rules := c.rules.resolve(path)
+
+	if len(rules) == 0 {
+		c.compareEquality(kind, path, expected, actual)
+		return
+	}
+
+	for _, rule := range rules {
+		c.compareRule(kind, path, rule, expected, actual)
+	}
+}
+
+// compareRule applies a single rule to the actual value.
+func (c *comparison) compareRule(kind string, path []string, rule MatchingRule, expected, actual interface{}) {
+	switch rule.Match {
+	case "regex":
+		c.compareRegex(kind, path, rule.Regex, expected, actual)
+	case "type", "":
+		c.compareType(kind, path, rule, expected, actual)
+	case "integer", "decimal", "number":
+		c.compareNumber(kind, path, rule.Match, expected, actual)
+	case "null":
+		if actual != nil {
+			c.mismatch(kind, path, expected, actual, "Expected %s to be null", describe(actual))
+		}
+	case "equality":
+		c.compareEquality(kind, path, expected, actual)
+	case "include":
+		if a, ok := actual.(string); !ok || !strings.Contains(a, rule.Value) {
+			c.mismatch(kind, path, expected, actual, "Expected %s to include %q", describe(actual), rule.Value)
+		}
+	case "date", "time", "timestamp":
+		c.compareDateTime(kind, path, rule, expected, actual)
+	case "values":
+		c.compareValues(kind, path, expected, actual)
+	default:
+		log.Printf("[WARN] matching: unsupported matcher '%s' at %s, falling back to equality", rule.Match, formatPath(path))
+		c.compareEquality(kind, path, expected, actual)
+	}
+}
+
+func (c *comparison) compareRegex(kind string, path []string, expression string, expected, actual interface{}) {
+	re, err := compileRegex(expression)
+	if err != nil {
+		log.Printf("[WARN] matching: unable to compile regex '%s' at %s, falling back to a type match: %v", expression, formatPath(path), err)
+		c.compareType(kind, path, MatchingRule{}, expected, actual)
+		return
+	}
+
+	var value string
+	switch a := actual.(type) {
+	case string:
+		value = a
+	case float64, bool, json.Number:
+		value = fmt.Sprint(a)
+	default:
+		c.mismatch(kind, path, expected, actual, "Expected %s to match '%s'", describe(actual), expression)
+		return
+	}
+
+	if !re.MatchString(value) {
+		c.mismatch(kind, path, expected, actual, "Expected '%s' to match '%s'", value, expression)
+	}
+}
+
+var (
+	integerRegex = regexp.MustCompile(`^-?\d+$`)
+	decimalRegex = regexp.MustCompile(`^-?\d+\.\d+$`)
+	numberRegex  = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+)
+
+// compareNumber checks the actual value is an "integer", "decimal" or any
+// "number". Values in the path, query and headers are always strings, and
+// so are checked by their format.
+func (c *comparison) compareNumber(kind string, path []string, matchType string, expected, actual interface{}) {
+	var value string
+	switch a := actual.(type) {
+	case json.Number:
+		value = a.String()
+	case float64:
+		value = strconv.FormatFloat(a, 'f', -1, 64)
+	case string:
+		if kind == "body" {
+			c.mismatch(kind, path, expected, actual, "Expected %s to be a%s %s", describe(actual), article(matchType), matchType)
+			return
+		}
+		value = a
+	default:
+		c.mismatch(kind, path, expected, actual, "Expected %s to be a%s %s", describe(actual), article(matchType), matchType)
+		return
+	}
+
+	var valid bool
+	switch matchType {
+	case "integer":
+		valid = integerRegex.MatchString(value)
+	case "decimal":
+		valid = decimalRegex.MatchString(value)
+	default:
+		valid = numberRegex.MatchString(value)
+	}
+
+	if !valid {
+		c.mismatch(kind, path, expected, actual, "Expected %s to be a%s %s", value, article(matchType), matchType)
+	}
+}
+
+func article(word string) string {
+	if strings.ContainsAny(word[:1], "aeiou") {
+		return "n"
+	}
+	return ""
+}
+
+// compareDateTime checks the actual value is a date or time in the format of
+// the rule.
+func (c *comparison) compareDateTime(kind string, path []string, rule MatchingRule, expected, actual interface{}) {
+	a, ok := actual.(string)
+	if !ok {
+		c.mismatch(kind, path, expected, actual, "Expected %s to be a %s string", describe(actual), rule.Match)
+		return
+	}
+
+	format := rule.Format
+	if format == "" {
+		format = map[string]string{
+			"date":      "yyyy-MM-dd",
+			"time":      "HH:mm:ss",
+			"timestamp": "yyyy-MM-dd'T'HH:mm:ss",
+		}[rule.Match]
+	}
+
+	layout, err := javaDateLayout(format)
+	if err != nil {
+		c.mismatch(kind, path, expected, actual, "Unable to match '%s': %v", a, err)
+		return
+	}
+	if _, err := time.Parse(layout, a); err != nil {
+		c.mismatch(kind, path, expected, actual, "Expected '%s' to be a %s in the format '%s'", a, rule.Match, format)
+	}
+}
+
+// compareValues compares each value of the actual map against the expected
+// values, ignoring the keys.
+func (c *comparison) compareValues(kind string, path []string, expected, actual interface{}) {
+	e, ok := expected.(map[string]interface{})
+	if !ok {
+		c.compareType(kind, path, MatchingRule{}, expected, actual)
+		return
+	}
+
+	a, ok := actual.(map[string]interface{})
+	if !ok {
+		c.mismatch(kind, path, expected, actual, "Expected %s to be the same type as %s", describe(actual), describe(expected))
+		return
+	}
+
+	keys := sortedKeys(e)
+	if len(keys) == 0 {
+		return
+	}
+
+	for _, key := range sortedKeys(a) {
+		example, ok := e[key]
+		if !ok {
+			example = e[keys[0]]
+		}
+		c.compare(kind, appendPath(path, key), example, a[key])
+	}
+}
+
+func (c *comparison) compareType(kind string, path []string, rule MatchingRule, expected, actual interface{}) {
+	if jsonType(expected) != jsonType(actual) {
+		c.mismatch(kind, path, expected, actual, "Expected %s to be the same type as %s", describe(actual), describe(expected))
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		c.compareMap(kind, path, e, actual.(map[string]interface{}))
+	case []interface{}:
+		a := actual.([]interface{})
+		if rule.Min != nil && len(a) < *rule.Min {
+			c.mismatch(kind, path, expected, actual, "Expected an array with at least %d element(s) but received %d", *rule.Min, len(a))
+		}
+		if rule.Max != nil && len(a) > *rule.Max {
+			c.mismatch(kind, path, expected, actual, "Expected an array with at most %d element(s) but received %d", *rule.Max, len(a))
+		}
+		if len(e) == 0 {
+			return
+		}
+		for i, item := range a {
+			c.compare(kind, appendPath(path, strconv.Itoa(i)), e[i%len(e)], item)
+		}
+	}
+}
+
+func (c *comparison) compareEquality(kind string, path []string, expected, actual interface{}) {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			c.mismatch(kind, path, expected, actual, "Expected %s but received %s", describe(expected), describe(actual))
+			return
+		}
+		c.compareMap(kind, path, e, a)
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			c.mismatch(kind, path, expected, actual, "Expected %s but received %s", describe(expected), describe(actual))
+			return
+		}
+		if len(e) != len(a) {
+			c.mismatch(kind, path, expected, actual, "Expected an array with %d element(s) but received %d", len(e), len(a))
+		}
+		for i := 0; i < len(e) && i < len(a); i++ {
+			c.compare(kind, appendPath(path, strconv.Itoa(i)), e[i], a[i])
+		}
+	default:
+		if !reflect.DeepEqual(normaliseNumber(expected), normaliseNumber(actual)) {
+			c.mismatch(kind, path, expected, actual, "Expected %s but received %s", describe(expected), describe(actual))
+		}
+	}
+}
+
+func (c *comparison) compareMap(kind string, path []string, expected, actual map[string]interface{}) {
+	for _, key := range sortedKeys(expected) {
+		value, ok := actual[key]
+		if !ok {
+			c.mismatch(kind, appendPath(path, key), expected[key], nil, "Could not find key '%s' in %s", key, formatPath(path))
+			continue
+		}
+		c.compare(kind, appendPath(path, key), expected[key], value)
+	}
+
+	if c.allowUnexpectedKeys {
+		return
+	}
+
+	for _, key := range sortedKeys(actual) {
+		if _, ok := expected[key]; !ok {
+			c.mismatch(kind, appendPath(path, key), nil, actual[key], "Unexpected key '%s' found in %s", key, formatPath(path))
+		}
+	}
+}
+
+// normaliseNumber converts a json.Number, as found in decoded bodies, to a
+// float64 so that it can be compared to the example values.
+func normaliseNumber(v interface{}) interface{} {
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+
+	return v
+}
+
+// jsonType returns the JSON type name of a decoded JSON value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	return reflect.TypeOf(v).String()
+}
+
+// describe renders a value for use in mismatch messages.
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}, []interface{}:
+		return fmt.Sprintf("%s %s", jsonType(v), objectToString(v))
+	}
+
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// compileRegex compiles a regular expression, translating the Ruby-isms
+// commonly found in pact files (e.g. "\Z") into their Go equivalents.
+func compileRegex(expression string) (*regexp.Regexp, error) {
+	return regexp.Compile(strings.Replace(expression, `\Z`, `\z`, -1))
+}
+
+// extractRules walks a value built from the DSL, replacing any embedded
+// matchers with their example values and recording the equivalent matching
+// rules against the given path.
+func extractRules(value interface{}, path []string, rules MatchingRules) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if matchType, ok := v["pact:matcher:type"].(string); ok {
+			return extractV3Rule(matchType, v, path, rules)
+		}
+
+		switch v["json_class"] {
+		case "Pact::SomethingLike":
+			rules.add(formatPath(path), MatchingRule{Match: "type"})
+			return extractRules(v["contents"], path, rules)
+		case "Pact::ArrayLike":
+			min := 1
+			if m, ok := v["min"].(float64); ok {
+				min = int(m)
+			}
+			rules.add(formatPath(path), MatchingRule{Match: "type", Min: &min})
+			item := extractRules(v["contents"], appendPath(path, "*"), rules)
+			items := make([]interface{}, min)
+			for i := range items {
+				items[i] = item
+			}
+			return items
+		case "Pact::Term":
+			data, _ := v["data"].(map[string]interface{})
+			matcher, _ := data["matcher"].(map[string]interface{})
+			regex, _ := matcher["s"].(string)
+			rules.add(formatPath(path), MatchingRule{Match: "regex", Regex: regex})
+			return data["generate"]
+		}
+
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = extractRules(item, appendPath(path, key), rules)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = extractRules(item, appendPath(path, strconv.Itoa(i)), rules)
+		}
+		return result
+	}
+
+	return value
+}
+
+// extractV3Rule records the rule for a version 3 matcher, returning its
+// example value.
+func extractV3Rule(matchType string, matcher map[string]interface{}, path []string, rules MatchingRules) interface{} {
+	rule := MatchingRule{Match: matchType}
+	rule.Regex, _ = matcher["regex"].(string)
+	rule.Format, _ = matcher["format"].(string)
+	if min, ok := matcher["min"].(float64); ok {
+		rule.Min = intPtr(int(min))
+	}
+	if max, ok := matcher["max"].(float64); ok {
+		rule.Max = intPtr(int(max))
+	}
+	if matchType == "include" {
+		rule.Value = fmt.Sprint(matcher["value"])
+	}
+	rules.add(formatPath(path), rule)
+
+	// Array matchers hold their examples, whose rules apply to every element
+	items, ok := matcher["value"].([]interface{})
+	if (rule.Min != nil || rule.Max != nil) && ok && len(items) > 0 {
+		item := extractRules(items[0], appendPath(path, "*"), rules)
+		result := make([]interface{}, len(items))
+		for i := range result {
+			result[i] = item
+		}
+		return result
+	}
+
+	// Values matchers apply to every value, regardless of the key
+	if example, ok := matcher["value"].(map[string]interface{}); ok && matchType == "values" {
+		result := make(map[string]interface{}, len(example))
+		for key, item := range example {
+			result[key] = extractRules(item, appendPath(path, "*"), rules)
+		}
+		return result
+	}
+
+	return extractRules(matcher["value"], path, rules)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,305 @@
+package dsl
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatching_parsePath(t *testing.T) {
+	cases := map[string][]string{
+		"$.body":                   {"$", "body"},
+		"$.body.items[*].name":     {"$", "body", "items", "*", "name"},
+		"$.body.items[0].*":        {"$", "body", "items", "0", "*"},
+		"$.headers.Content-Type":   {"$", "headers", "Content-Type"},
+		"$.body['with space'].foo": {"$", "body", "with space", "foo"},
+		"body":                     nil,
+	}
+
+	for expression, want := range cases {
+		if got := parsePath(expression); !reflect.DeepEqual(got, want) {
+			t.Fatalf("parsePath(%q): want %v, got %v", expression, want, got)
+		}
+	}
+}
+
+func TestMatching_formatPath(t *testing.T) {
+	cases := map[string][]string{
+		"$.body":                   {"$", "body"},
+		"$.body.items[*].name":     {"$", "body", "items", "*", "name"},
+		"$.body['with space'].foo": {"$", "body", "with space", "foo"},
+	}
+
+	for want, path := range cases {
+		if got := formatPath(path); got != want {
+			t.Fatalf("formatPath(%v): want %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestMatching_resolveMostSpecific(t *testing.T) {
+	rules := MatchingRules{
+		"$.body":            {{Match: "type"}},
+		"$.body.items[*].a": {{Match: "regex", Regex: "\\d+"}},
+	}
+
+	if r := rules.resolve([]string{"$", "body", "items", "3", "a"}); len(r) != 1 || r[0].Match != "regex" {
+		t.Fatalf("expected the regex rule to apply, got %v", r)
+	}
+
+	if r := rules.resolve([]string{"$", "body", "items", "3", "b"}); len(r) != 1 || r[0].Match != "type" {
+		t.Fatalf("expected the type rule to cascade, got %v", r)
+	}
+
+	if r := rules.resolve([]string{"$", "headers", "Accept"}); len(r) != 0 {
+		t.Fatalf("expected no rules, got %v", r)
+	}
+}
+
+func TestMatching_resolveHeadersIgnoreCase(t *testing.T) {
+	rules := MatchingRules{
+		"$.headers.Content-Type": {{Match: "regex", Regex: "json"}},
+	}
+
+	if r := rules.resolve([]string{"$", "headers", "content-type"}); len(r) != 1 {
+		t.Fatalf("expected header rule to apply regardless of case, got %v", r)
+	}
+}
+
+func TestMatching_extractRules(t *testing.T) {
+	var body interface{}
+	data, _ := json.Marshal(map[string]interface{}{
+		"name":  Like("billy"),
+		"id":    Term("1234", "\\d+"),
+		"items": EachLike(map[string]interface{}{"colour": Term("red", "red|blue")}, 2),
+		"plain": "value",
+	})
+	json.Unmarshal(data, &body)
+
+	rules := make(MatchingRules)
+	example := extractRules(body, []string{"$", "body"}, rules)
+
+	want := map[string]interface{}{
+		"name": "billy",
+		"id":   "1234",
+		"items": []interface{}{
+			map[string]interface{}{"colour": "red"},
+			map[string]interface{}{"colour": "red"},
+		},
+		"plain": "value",
+	}
+	if !reflect.DeepEqual(example, want) {
+		t.Fatalf("want example %v, got %v", want, example)
+	}
+
+	for _, path := range []string{"$.body.name", "$.body.id", "$.body.items", "$.body.items[*].colour"} {
+		if _, ok := rules[path]; !ok {
+			t.Fatalf("expected a rule for %s, got %v", path, rules)
+		}
+	}
+
+	if *rules["$.body.items"][0].Min != 2 {
+		t.Fatalf("expected a min of 2, got %d", *rules["$.body.items"][0].Min)
+	}
+}
+
+func TestMatching_compare(t *testing.T) {
+	rules := MatchingRules{
+		"$.body.name":           {{Match: "type"}},
+		"$.body.id":             {{Match: "regex", Regex: "^\\d+$"}},
+		"$.body.items":          {{Match: "type", Min: intPtr(1)}},
+		"$.body.items[*].price": {{Match: "type"}},
+	}
+	expected := map[string]interface{}{
+		"name":  "billy",
+		"id":    "1",
+		"exact": true,
+		"items": []interface{}{map[string]interface{}{"price": 1.5}},
+	}
+
+	cases := []struct {
+		actual     string
+		mismatches int
+	}{
+		{`{"name":"sally","id":"42","exact":true,"items":[{"price":2},{"price":3}]}`, 0},
+		{`{"name":1,"id":"42","exact":true,"items":[{"price":2}]}`, 1},
+		{`{"name":"sally","id":"abc","exact":true,"items":[{"price":2}]}`, 1},
+		{`{"name":"sally","id":"42","exact":false,"items":[{"price":2}]}`, 1},
+		{`{"name":"sally","id":"42","exact":true,"items":[]}`, 1},
+		{`{"name":"sally","id":"42","exact":true,"items":[{"price":"free"}]}`, 1},
+		{`{"name":"sally","id":"42","items":[{"price":2}]}`, 1},
+		{`{"name":"sally","id":"42","exact":true,"items":[{"price":2}],"extra":1}`, 1},
+	}
+
+	for _, tc := range cases {
+		var actual interface{}
+		json.Unmarshal([]byte(tc.actual), &actual)
+		c := &comparison{rules: rules}
+		c.compare("body", []string{"$", "body"}, expected, actual)
+
+		if len(c.mismatches) != tc.mismatches {
+			t.Fatalf("%s: expected %d mismatches, got %v", tc.actual, tc.mismatches, c.mismatches)
+		}
+	}
+}
+
+func TestMatching_compareAllowUnexpectedKeys(t *testing.T) {
+	c := &comparison{allowUnexpectedKeys: true}
+	c.compare("body", []string{"$", "body"}, map[string]interface{}{"a": "b"}, map[string]interface{}{"a": "b", "c": "d"})
+
+	if len(c.mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", c.mismatches)
+	}
+}
+
+func TestMatching_compareInvalidRegexFallsBackToType(t *testing.T) {
+	c := &comparison{rules: MatchingRules{"$.body": {{Match: "regex", Regex: "(?!foo)"}}}}
+	c.compare("body", []string{"$", "body"}, "bar", "baz")
+
+	if len(c.mismatches) != 0 {
+		t.Fatalf("expected no mismatches, got %v", c.mismatches)
+	}
+}
+
+func TestMatching_requestMatch(t *testing.T) {
+	data, _ := json.Marshal((&Interaction{}).
+		UponReceiving("a request for users").
+		WithRequest(Request{
+			Method:  "GET",
+			Path:    Term("/users/1", "/users/\\d+"),
+			Query:   MapMatcher{"sort": String("asc")},
+			Headers: MapMatcher{"Authorization": Term("Bearer 1234", "Bearer .+")},
+		}))
+	i, err := newPactInteraction(data)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	headers := http.Header{"Authorization": []string{"Bearer abcd"}}
+	if m := i.Request.match("get", "/users/22", map[string][]string{"sort": {"asc"}}, headers, nil); len(m) != 0 {
+		t.Fatalf("expected no mismatches, got %v", m)
+	}
+
+	if m := i.Request.match("POST", "/accounts/22", map[string][]string{"sort": {"desc"}, "page": {"1"}}, http.Header{}, nil); len(m) != 5 {
+		t.Fatalf("expected 5 mismatches, got %v", m)
+	}
+}
+
+func TestMatching_responseMatch(t *testing.T) {
+	data, _ := json.Marshal((&Interaction{}).
+		UponReceiving("a request for users").
+		WillRespondWith(Response{
+			Status:  200,
+			Headers: MapMatcher{"Content-Type": String("application/json; charset=utf-8")},
+			Body:    Like(map[string]interface{}{"name": "billy"}),
+		}))
+	i, err := newPactInteraction(data)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	headers := http.Header{"Content-Type": []string{"application/json;charset=utf-8"}}
+	if m := i.Response.match(200, headers, []byte(`{"name":"sally","extra":true}`)); len(m) != 0 {
+		t.Fatalf("expected no mismatches, got %v", m)
+	}
+
+	if m := i.Response.match(404, http.Header{}, []byte(`not json`)); len(m) != 3 {
+		t.Fatalf("expected 3 mismatches, got %v", m)
+	}
+}
+
+func TestMatching_extractV3Rules(t *testing.T) {
+	var body interface{}
+	data, _ := json.Marshal(map[string]interface{}{
+		"id":      Integer(),
+		"items":   MinMaxLike(map[string]interface{}{"name": Includes("bill")}, 2, 5),
+		"created": Date("dd/MM/yyyy"),
+		"scores":  Values(map[string]interface{}{"maths": Decimal()}),
+	})
+	json.Unmarshal(data, &body)
+
+	rules := make(MatchingRules)
+	example := extractRules(body, []string{"$", "body"}, rules)
+
+	want := map[string]interface{}{
+		"id": 42.0,
+		"items": []interface{}{
+			map[string]interface{}{"name": "bill"},
+			map[string]interface{}{"name": "bill"},
+		},
+		"created": "01/02/2000",
+		"scores":  map[string]interface{}{"maths": 42.0},
+	}
+	if !reflect.DeepEqual(example, want) {
+		t.Fatalf("want example %v, got %v", want, example)
+	}
+
+	cases := map[string]MatchingRule{
+		"$.body.id":            {Match: "integer"},
+		"$.body.items":         {Match: "type", Min: intPtr(2), Max: intPtr(5)},
+		"$.body.items[*].name": {Match: "include", Value: "bill"},
+		"$.body.created":       {Match: "date", Format: "dd/MM/yyyy"},
+		"$.body.scores":        {Match: "values"},
+		"$.body.scores[*]":     {Match: "decimal"},
+	}
+	for path, rule := range cases {
+		if r := rules[path]; len(r) != 1 || !reflect.DeepEqual(r[0], rule) {
+			t.Fatalf("expected rule %v for %s, got %v", rule, path, rules)
+		}
+	}
+}
+
+func TestMatching_compareV3(t *testing.T) {
+	cases := []struct {
+		rule     MatchingRule
+		expected string
+		actual   string
+		valid    bool
+	}{
+		{MatchingRule{Match: "integer"}, `1`, `42`, true},
+		{MatchingRule{Match: "integer"}, `1`, `42.5`, false},
+		{MatchingRule{Match: "integer"}, `1`, `"42"`, false},
+		{MatchingRule{Match: "decimal"}, `1.5`, `42.0`, true},
+		{MatchingRule{Match: "decimal"}, `1.5`, `42`, false},
+		{MatchingRule{Match: "number"}, `1`, `4.2e3`, true},
+		{MatchingRule{Match: "null"}, `null`, `null`, true},
+		{MatchingRule{Match: "null"}, `null`, `"a"`, false},
+		{MatchingRule{Match: "equality"}, `"billy"`, `"billy"`, true},
+		{MatchingRule{Match: "equality"}, `"billy"`, `"sally"`, false},
+		{MatchingRule{Match: "include", Value: "ill"}, `"billy"`, `"a billy goat"`, true},
+		{MatchingRule{Match: "include", Value: "ill"}, `"billy"`, `"sally"`, false},
+		{MatchingRule{Match: "date", Format: "dd/MM/yyyy"}, `"01/02/2000"`, `"31/12/2019"`, true},
+		{MatchingRule{Match: "date", Format: "dd/MM/yyyy"}, `"01/02/2000"`, `"2019-12-31"`, false},
+		{MatchingRule{Match: "time", Format: "HH:mm"}, `"12:30"`, `"23:59"`, true},
+		{MatchingRule{Match: "timestamp"}, `"2000-02-01T12:30:00"`, `"2019-12-31T23:59:59"`, true},
+		{MatchingRule{Match: "values"}, `{"a":1}`, `{"x":2,"y":3}`, true},
+		{MatchingRule{Match: "values"}, `{"a":1}`, `{"x":"2"}`, false},
+	}
+
+	for _, tc := range cases {
+		var expected, actual interface{}
+		json.Unmarshal([]byte(tc.expected), &expected)
+		decoder := json.NewDecoder(strings.NewReader(tc.actual))
+		decoder.UseNumber()
+		decoder.Decode(&actual)
+
+		c := &comparison{rules: MatchingRules{"$.body": {tc.rule}}}
+		c.compare("body", []string{"$", "body"}, expected, actual)
+
+		if valid := len(c.mismatches) == 0; valid != tc.valid {
+			t.Fatalf("%v: expected %s to match %s: %v, got %v", tc.rule, tc.actual, tc.expected, tc.valid, c.mismatches)
+		}
+	}
+}
+
+func TestMatching_compareIntegerInPath(t *testing.T) {
+	c := &comparison{rules: MatchingRules{"$.query.id": {{Match: "integer"}}}}
+	c.compare("query", []string{"$", "query", "id"}, "1", "42")
+	c.compare("query", []string{"$", "query", "id"}, "1", "4x")
+
+	if len(c.mismatches) != 1 {
+		t.Fatalf("expected 1 mismatch, got %v", c.mismatches)
+	}
+}
@@ -3,14 +3,36 @@ package dsl
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/pact-foundation/pact-go/types"
 )
 
 // StateHandler is a provider function that sets up a given state before
 // the provider interaction is validated
-type StateHandler func(State) error
+type StateHandler = types.StateHandler
 
 // StateHandlers is a list of StateHandler's
-type StateHandlers map[string]StateHandler
+type StateHandlers = types.StateHandlers
+
+// StateAction is the stage of a provider state's lifecycle, either
+// StateSetup or StateTeardown
+type StateAction = types.StateAction
+
+const (
+	// StateSetup sets up a provider state before an interaction is verified.
+	StateSetup = types.StateSetup
+
+	// StateTeardown tears down a provider state after an interaction is
+	// verified.
+	StateTeardown = types.StateTeardown
+)
+
+// StateLifecycleHandler is a provider function that sets up a given state
+// before the provider interaction is validated, and tears it down afterwards
+type StateLifecycleHandler = types.StateLifecycleHandler
+
+// StateLifecycleHandlers is a list of StateLifecycleHandler's
+type StateLifecycleHandlers = types.StateLifecycleHandlers
 
 // MessageHandler is a provider function that generates a
 // message for a Consumer given a Message context (state, description etc.)
@@ -51,10 +73,7 @@ type Message struct {
 
 // State specifies how the system should be configured when
 // verified. e.g. "user A exists"
-type State struct {
-	Name   string                 `json:"name"`
-	Params map[string]interface{} `json:"params,omitempty"`
-}
+type State = types.State
 
 // Given specifies a provider state. Optional.
 func (p *Message) Given(state string) *Message {
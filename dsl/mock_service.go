@@ -30,14 +30,26 @@ type MockService struct {
 	// are split over multiple files and instantiations of a Mock Server
 	// See https://github.com/pact-foundation/pact-ruby/blob/master/documentation/configuration.md#pactfile_write_mode
 	PactFileWriteMode string
+
+	// Native is true when the Mock Service is a NativeMockService, which
+	// supports multiple provider states and version 3 matchers. Otherwise
+	// interactions are converted to those the Ruby Mock Service supports.
+	Native bool
 }
 
 // call sends a message to the Pact service
 func (m *MockService) call(method string, url string, content interface{}) error {
+	_, _, err := m.send(method, url, content, nil)
+	return err
+}
+
+// send makes a request to the Mock Service, returning the response and its
+// body, or an error if the request was unsuccessful.
+func (m *MockService) send(method string, url string, content interface{}, headers map[string]string) (*http.Response, []byte, error) {
 	body, err := json.Marshal(content)
 	if err != nil {
 		fmt.Println(err)
-		return err
+		return nil, nil, err
 	}
 
 	client := &http.Client{}
@@ -48,23 +60,26 @@ func (m *MockService) call(method string, url string, content interface{}) error
 		req, err = http.NewRequest(method, url, nil)
 	}
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	req.Header.Set("X-Pact-Mock-Service", "true")
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	responseBody, err := ioutil.ReadAll(res.Body)
 	res.Body.Close()
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return errors.New(string(responseBody))
+		return res, responseBody, errors.New(string(responseBody))
 	}
-	return err
+	return res, responseBody, err
 }
 
 // DeleteInteractions removes any previous Mock Service Interactions.
@@ -74,18 +89,46 @@ func (m *MockService) DeleteInteractions() error {
 	return m.call("DELETE", url, nil)
 }
 
-// AddInteraction adds a new Pact Mock Service interaction.
+// AddInteraction adds a new Pact Mock Service interaction. Unless the Mock
+// Service is Native, version 3 matchers are converted to those supported by
+// the Ruby Mock Service, and only the last provider state is sent.
 func (m *MockService) AddInteraction(interaction *Interaction) error {
 	log.Println("[DEBUG] mock service add interaction")
 	url := fmt.Sprintf("%s/interactions", m.BaseURL)
-	return m.call("POST", url, interaction)
+	if m.Native {
+		return m.call("POST", url, interaction)
+	}
+
+	content, err := rubyContent(interaction)
+	if err != nil {
+		return err
+	}
+
+	// The Ruby Mock Service only supports a single provider state, without
+	// parameters
+	if len(interaction.States) > 1 || (len(interaction.States) == 1 && len(interaction.States[0].Params) > 0) {
+		log.Printf("[WARN] mock service: multiple provider states and parameters are not supported by the Ruby Mock Service, only '%s' will be used", interaction.State)
+	}
+	delete(content.(map[string]interface{}), "providerStates")
+
+	return m.call("POST", url, content)
 }
 
-// Verify confirms that all interactions were called.
+// Verify confirms that all interactions were called. The differences found
+// are returned as a *VerificationError.
 func (m *MockService) Verify() error {
 	log.Println("[DEBUG] mock service verify")
 	url := fmt.Sprintf("%s/interactions/verification", m.BaseURL)
-	return m.call("GET", url, nil)
+	res, body, err := m.send("GET", url, nil, map[string]string{"Accept": "application/json, text/plain"})
+	if err == nil || res == nil {
+		return err
+	}
+
+	verr := newVerificationError(res.Header.Get("Content-Type"), body)
+	if len(verr.Missing) == 0 && len(verr.Unexpected) == 0 {
+		return err
+	}
+	return verr
 }
 
 // WritePact writes the pact file to disk.
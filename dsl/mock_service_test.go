@@ -7,6 +7,7 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/pact-foundation/pact-go/utils"
@@ -175,3 +176,41 @@ func TestMockService_callInvalidObject(t *testing.T) {
 		t.Fatalf("Expected error but got none")
 	}
 }
+
+func TestMockService_AddInteractionRuby(t *testing.T) {
+	var body string
+	ms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		body = string(data)
+	}))
+	defer ms.Close()
+
+	mockService := &MockService{
+		BaseURL: ms.URL,
+	}
+	i := (&Interaction{}).
+		GivenWithParams("Some state", map[string]interface{}{"id": 1}).
+		UponReceiving("Some name for the test").
+		WithRequest(Request{}).
+		WillRespondWith(Response{
+			Body: map[string]interface{}{"id": Integer()},
+		})
+	err := mockService.AddInteraction(i)
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+
+	for _, s := range []string{`"providerState":"Some state"`, `"json_class":"Pact::SomethingLike"`} {
+		if !strings.Contains(body, s) {
+			t.Fatalf("Expected %s in %s", s, body)
+		}
+	}
+
+	for _, s := range []string{"providerStates", "pact:matcher:type"} {
+		if strings.Contains(body, s) {
+			t.Fatalf("Expected no %s in %s", s, body)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package dsl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NativeMockService is an in-process implementation of the Pact Mock Service,
+// removing the need to have the Ruby pact-mock-service installed.
+//
+// It exposes the same administration API as the Ruby Mock Service, so that
+// it can be driven by a MockService, and matches all other requests against
+// the registered interactions.
+type NativeMockService struct {
+	// Consumer name.
+	Consumer string
+
+	// Provider name.
+	Provider string
+
+	// PactDir is the directory Pact files are written to.
+	PactDir string
+
+	// PactFileWriteMode specifies how to write to the Pact file.
+	PactFileWriteMode string
+
+	// SpecificationVersion of the Pact file to write.
+	SpecificationVersion int
+
+	server *httptest.Server
+	mu     sync.Mutex
+
+	// Interactions expected by the current test.
+	expected []*expectedInteraction
+
+	// Requests received that did not match any expected interaction.
+	unexpected []UnexpectedRequest
+
+	// Interactions of the tests that passed verification, to be written to
+	// the Pact file.
+	interactions []*PactInteraction
+}
+
+// expectedInteraction tracks whether a registered interaction was called.
+type expectedInteraction struct {
+	interaction *PactInteraction
+	calls       int
+}
+
+// Start the mock service listening on the given network address,
+// e.g. "localhost:1234".
+func (m *NativeMockService) Start(network string, address string) error {
+	if err := checkPactFileWriteMode(m.PactFileWriteMode); err != nil {
+		return err
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("unable to start native mock service on %s: %v", address, err)
+	}
+
+	m.server = httptest.NewUnstartedServer(m)
+	m.server.Listener.Close()
+	m.server.Listener = ln
+	m.server.Start()
+	log.Println("[DEBUG] native mock service listening on:", m.server.URL)
+
+	return nil
+}
+
+// URL is the base URL of the running mock service.
+func (m *NativeMockService) URL() string {
+	if m.server == nil {
+		return ""
+	}
+	return m.server.URL
+}
+
+// Stop the mock service.
+func (m *NativeMockService) Stop() {
+	if m.server != nil {
+		log.Println("[DEBUG] stopping native mock service:", m.server.URL)
+		m.server.Close()
+		m.server = nil
+	}
+}
+
+// AddInteraction registers an interaction the mock service should expect.
+func (m *NativeMockService) AddInteraction(interaction *Interaction) error {
+	data, err := json.Marshal(interaction)
+	if err != nil {
+		return err
+	}
+
+	return m.addInteraction(data)
+}
+
+func (m *NativeMockService) addInteraction(data []byte) error {
+	i, err := newPactInteraction(data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log.Printf("[DEBUG] native mock service: registered interaction '%s' (%s)", i.Description, i.Request)
+	m.expected = append(m.expected, &expectedInteraction{interaction: i})
+
+	return nil
+}
+
+// DeleteInteractions removes all expected interactions and any record of
+// unexpected requests, ready for the next test.
+func (m *NativeMockService) DeleteInteractions() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expected = nil
+	m.unexpected = nil
+}
+
+// Verify confirms that all expected interactions were called, and that no
+// unexpected requests were received. As with the Ruby Mock Service, only the
+// interactions of a test that passes verification are written to the Pact
+// file.
+func (m *NativeMockService) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	verr := &VerificationError{Unexpected: append([]UnexpectedRequest(nil), m.unexpected...)}
+	for _, e := range m.expected {
+		if e.calls == 0 {
+			verr.Missing = append(verr.Missing, MissingRequest{
+				Description: e.interaction.Description,
+				Request:     e.interaction.Request.String(),
+			})
+		}
+	}
+
+	if len(verr.Missing) > 0 || len(verr.Unexpected) > 0 {
+		return verr
+	}
+
+	for _, e := range m.expected {
+		m.recordInteraction(e.interaction)
+	}
+
+	return nil
+}
+
+// WritePact writes all interactions that have been verified to the Pact file.
+func (m *NativeMockService) WritePact() error {
+	m.mu.Lock()
+	pact := &PactFile{
+		Consumer:     PactName{Name: m.Consumer},
+		Provider:     PactName{Name: m.Provider},
+		Interactions: append([]*PactInteraction{}, m.interactions...),
+	}
+	writeMode := m.PactFileWriteMode
+	m.mu.Unlock()
+
+	if pact.Consumer.Name == "" || pact.Provider.Name == "" {
+		return errors.New("Consumer and Provider name need to be provided")
+	}
+	pact.SetSpecificationVersion(m.SpecificationVersion)
+
+	return WritePactFile(pact, m.PactDir, writeMode)
+}
+
+// ServeHTTP handles both the administration API used by a MockService, and
+// the requests made by the consumer under test.
+func (m *NativeMockService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Pact-Mock-Service") != "" {
+		m.serveAdmin(w, r)
+		return
+	}
+
+	m.serveInteraction(w, r)
+}
+
+// serveAdmin implements the subset of the Ruby Mock Service administration
+// API used by the DSL.
+func (m *NativeMockService) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/interactions" && r.Method == http.MethodPost:
+		if err = m.addInteraction(body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "Registered interactions")
+	case r.URL.Path == "/interactions" && r.Method == http.MethodDelete:
+		m.DeleteInteractions()
+		fmt.Fprint(w, "Deleted interactions")
+	case r.URL.Path == "/interactions/verification" && r.Method == http.MethodGet:
+		if err = m.Verify(); err != nil {
+			// Clients asking for JSON receive the differences in full
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(err)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "Interactions matched")
+	case r.URL.Path == "/pact" && r.Method == http.MethodPost:
+		var details struct {
+			Consumer struct {
+				Name string `json:"name"`
+			} `json:"consumer"`
+			Provider struct {
+				Name string `json:"name"`
+			} `json:"provider"`
+			PactFileWriteMode string `json:"pactFileWriteMode"`
+		}
+		json.Unmarshal(body, &details)
+		m.mu.Lock()
+		if details.Consumer.Name != "" {
+			m.Consumer = details.Consumer.Name
+		}
+		if details.Provider.Name != "" {
+			m.Provider = details.Provider.Name
+		}
+		if details.PactFileWriteMode != "" {
+			m.PactFileWriteMode = details.PactFileWriteMode
+		}
+		m.mu.Unlock()
+		if err = m.WritePact(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "{}")
+	default:
+		http.Error(w, fmt.Sprintf("unknown administration request: %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+	}
+}
+
+// serveInteraction finds the interaction matching the request and replays
+// its response.
+func (m *NativeMockService) serveInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	description := fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+	mismatches := make(map[string][]Mismatch)
+	for _, e := range m.expected {
+		diff := e.interaction.Request.match(r.Method, r.URL.Path, r.URL.Query(), r.Header, body)
+		if len(diff) == 0 {
+			log.Printf("[DEBUG] native mock service: matched '%s' to interaction '%s'", description, e.interaction.Description)
+			e.calls++
+			writeResponse(w, e.interaction.Response)
+			return
+		}
+
+		// Only report differences for interactions with the same method and path,
+		// to avoid noise from unrelated interactions
+		if strings.EqualFold(e.interaction.Request.Method, r.Method) && OnlyMismatches(diff, "query", "header", "body") {
+			mismatches[e.interaction.Description] = diff
+		}
+	}
+
+	log.Printf("[WARN] native mock service: no interaction found for '%s'", description)
+	m.unexpected = append(m.unexpected, UnexpectedRequest{
+		Request:    description,
+		Mismatches: mismatches,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":           fmt.Sprintf("No interaction found for %s", description),
+		"interaction_diffs": mismatches,
+	})
+}
+
+// recordInteraction stores a verified interaction for the Pact file, replacing
+// any previous interaction with the same description and provider state.
+func (m *NativeMockService) recordInteraction(interaction *PactInteraction) {
+	for i, existing := range m.interactions {
+		if existing.key() == interaction.key() {
+			m.interactions[i] = interaction
+			return
+		}
+	}
+	m.interactions = append(m.interactions, interaction)
+}
+
+func sortedMismatchKeys(m map[string][]Mismatch) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Send writes the example response of an interaction.
+func (r PactResponse) Send(w http.ResponseWriter) {
+	writeResponse(w, r)
+}
+
+// writeResponse sends the example response of an interaction.
+func writeResponse(w http.ResponseWriter, response PactResponse) {
+	for key, value := range response.Headers {
+		w.Header().Set(key, value)
+	}
+
+	var body []byte
+	switch b := response.Body.(type) {
+	case nil:
+	case string:
+		body = []byte(b)
+	default:
+		body, _ = json.Marshal(b)
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+
+	w.WriteHeader(response.Status)
+	w.Write(body)
+}
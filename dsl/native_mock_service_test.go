@@ -0,0 +1,347 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupNativeMockService(t *testing.T) (*NativeMockService, *MockService) {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	ms := &NativeMockService{
+		Consumer: "billy",
+		Provider: "bobby",
+		PactDir:  dir,
+	}
+	if err = ms.Start("tcp", "localhost:0"); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return ms, &MockService{
+		Native:   true,
+		BaseURL:  ms.URL(),
+		Consumer: "billy",
+		Provider: "bobby",
+	}
+}
+
+func userInteraction() *Interaction {
+	return (&Interaction{}).
+		Given("User billy exists").
+		UponReceiving("A request for billy").
+		WithRequest(Request{
+			Method: "GET",
+			Path:   Term("/users/1", "/users/\\d+"),
+		}).
+		WillRespondWith(Response{
+			Status:  200,
+			Headers: MapMatcher{"Content-Type": String("application/json")},
+			Body: Match(&struct {
+				Name string `json:"name" pact:"example=billy"`
+			}{}),
+		})
+}
+
+func TestNativeMockService_Interaction(t *testing.T) {
+	ms, client := setupNativeMockService(t)
+	defer ms.Stop()
+	defer os.RemoveAll(ms.PactDir)
+
+	if err := client.AddInteraction(userInteraction()); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	res, err := http.Get(fmt.Sprintf("%s/users/22", ms.URL()))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer res.Body.Close()
+
+	var user map[string]interface{}
+	json.NewDecoder(res.Body).Decode(&user)
+	if res.StatusCode != 200 || user["name"] != "billy" {
+		t.Fatalf("expected the example response but got %d %v", res.StatusCode, user)
+	}
+
+	if err = client.Verify(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = client.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(ms.PactDir, "billy-bobby.json"))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"description": "A request for billy"`, `"providerState": "User billy exists"`, `"$.path"`, `"$.body.name"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+}
+
+func TestNativeMockService_VerifyMissing(t *testing.T) {
+	ms, client := setupNativeMockService(t)
+	defer ms.Stop()
+	defer os.RemoveAll(ms.PactDir)
+
+	client.AddInteraction(userInteraction())
+	err := client.Verify()
+
+	if err == nil || !strings.Contains(err.Error(), "Missing requests:\n\tGET /users/1") {
+		t.Fatalf("expected a missing request error, got %v", err)
+	}
+	verr, ok := err.(*VerificationError)
+	if !ok || len(verr.Missing) != 1 || verr.Missing[0].Description != userInteraction().Description {
+		t.Fatalf("expected a verification error with the missing interaction, got %#v", err)
+	}
+
+	if err = client.DeleteInteractions(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = client.Verify(); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestNativeMockService_VerifyUnexpected(t *testing.T) {
+	ms, client := setupNativeMockService(t)
+	defer ms.Stop()
+	defer os.RemoveAll(ms.PactDir)
+
+	ms.AddInteraction(userInteraction())
+	res, err := http.Post(fmt.Sprintf("%s/users/1", ms.URL()), "application/json", nil)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected a 500 for an unexpected request, got %d", res.StatusCode)
+	}
+
+	err = client.Verify()
+	if err == nil || !strings.Contains(err.Error(), "Unexpected requests:\n\tPOST /users/1") {
+		t.Fatalf("expected an unexpected request error, got %v", err)
+	}
+	verr, ok := err.(*VerificationError)
+	if !ok || len(verr.Unexpected) != 1 || verr.Unexpected[0].Request != "POST /users/1" {
+		t.Fatalf("expected a verification error with the unexpected request, got %#v", err)
+	}
+
+	// The error does not share the requests recorded by the mock service
+	verr = ms.Verify().(*VerificationError)
+	verr.Unexpected[0].Request = "GET /users/2"
+	if verr = ms.Verify().(*VerificationError); verr.Unexpected[0].Request != "POST /users/1" {
+		t.Fatalf("expected the unexpected requests to be copied, got %v", verr.Unexpected)
+	}
+}
+
+func TestNativeMockService_WritePactFailedVerification(t *testing.T) {
+	ms, client := setupNativeMockService(t)
+	defer ms.Stop()
+	defer os.RemoveAll(ms.PactDir)
+
+	ms.AddInteraction(userInteraction())
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, _ := http.NewRequest(method, fmt.Sprintf("%s/users/1", ms.URL()), nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		res.Body.Close()
+	}
+
+	if err := client.Verify(); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if err := client.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	pact, err := ReadPactFile(filepath.Join(ms.PactDir, "billy-bobby.json"))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(pact.Interactions) != 0 {
+		t.Fatalf("expected the interactions of a failed test not to be written, got %d", len(pact.Interactions))
+	}
+}
+
+func TestNativeMockService_WritePactFail(t *testing.T) {
+	ms := &NativeMockService{}
+
+	if err := ms.WritePact(); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestNativeMockService_StartFail(t *testing.T) {
+	ms := &NativeMockService{}
+
+	if err := ms.Start("tcp", "%%"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestNativeMockService_pactFileName(t *testing.T) {
+	if name := pactFileName("My Consumer", "My Provider"); name != "my_consumer-my_provider.json" {
+		t.Fatalf("unexpected pact file name: %s", name)
+	}
+}
+
+func TestPact_NativeMockServer(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	pact := &Pact{
+		Consumer:         "billy",
+		Provider:         "bobby",
+		PactDir:          dir,
+		LogLevel:         "DEBUG",
+		NativeMockServer: true,
+	}
+	defer pact.Teardown()
+
+	pact.AddInteraction().
+		Given("User billy exists").
+		UponReceiving("A request for billy").
+		WithRequest(Request{
+			Method: "GET",
+			Path:   String("/users/1"),
+		}).
+		WillRespondWith(Response{
+			Status: 200,
+		})
+
+	err := pact.Verify(func() error {
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d/users/1", pact.Server.Port))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = pact.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "billy-bobby.json")); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestPact_NativeMockServerV3Matchers(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	pact := &Pact{
+		Consumer:             "billy",
+		Provider:             "bobby",
+		PactDir:              dir,
+		LogLevel:             "DEBUG",
+		NativeMockServer:     true,
+		SpecificationVersion: 3,
+	}
+	defer pact.Teardown()
+
+	pact.AddInteraction().
+		UponReceiving("A request for billy").
+		WithRequest(Request{
+			Method: "GET",
+			Path:   String("/users/1"),
+		}).
+		WillRespondWith(Response{
+			Status: 200,
+			Body: map[string]interface{}{
+				"id":   Integer(),
+				"tags": MinLike("admin", 1),
+			},
+		})
+
+	err := pact.Verify(func() error {
+		res, err := http.Get(fmt.Sprintf("http://localhost:%d/users/1", pact.Server.Port))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if err = pact.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "billy-bobby.json"))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"$.id": {`, `"match": "integer"`, `"$.tags": {`, `"version": "3.0.0"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+}
+
+func TestNativeMockService_ProviderStateParams(t *testing.T) {
+	ms, client := setupNativeMockService(t)
+	defer ms.Stop()
+	defer os.RemoveAll(ms.PactDir)
+	ms.SpecificationVersion = 3
+
+	client.AddInteraction((&Interaction{}).
+		GivenWithParams("User exists", map[string]interface{}{"id": 22}).
+		Given("User is an admin").
+		UponReceiving("A request for an admin").
+		WithRequest(Request{
+			Method: "GET",
+			Path:   String("/admins/22"),
+		}).
+		WillRespondWith(Response{
+			Status: 200,
+		}))
+
+	res, err := http.Get(fmt.Sprintf("%s/admins/22", ms.URL()))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+
+	if err = client.Verify(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err = client.WritePact(); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	pact, err := ReadPactFile(filepath.Join(ms.PactDir, "billy-bobby.json"))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	states := pact.Interactions[0].ProviderStates
+	if len(states) != 2 || states[0].Params["id"] != 22.0 || states[1].Name != "User is an admin" {
+		t.Fatalf("expected both provider states to be written, got %v", states)
+	}
+}
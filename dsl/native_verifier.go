@@ -0,0 +1,495 @@
+package dsl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// NativeVerifier is an in-process implementation of the Pact Provider
+// Verifier, removing the need to have the Ruby pact-provider-verifier
+// installed.
+//
+// It replays each interaction in the given Pact files against the Provider,
+// and compares the actual responses to those expected by the Consumer.
+type NativeVerifier struct {
+	// Client used to communicate with the Provider and to fetch Pact files,
+	// unless the request has a BrokerTransport. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Network of the Provider API e.g. 'tcp', 'tcp4', 'tcp6'.
+	// Defaults to 'tcp'.
+	Network string
+
+	// Timeout specifies how long to wait for the Provider API to start.
+	// Defaults to 10s.
+	Timeout time.Duration
+
+	// Handler is the Provider API to verify in-process. When set, requests
+	// to the Provider, including provider state setup, are served by the
+	// Handler directly and no port is required.
+	Handler http.Handler
+}
+
+// VerifyProvider verifies all interactions in the Pact files of the request
+// against a running Provider API.
+func (v *NativeVerifier) VerifyProvider(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+	return v.VerifyProviderContext(context.Background(), request)
+}
+
+// VerifyProviderContext is VerifyProvider with a context, which cancels the
+// fetching of Pact files and stops the verification between interactions.
+func (v *NativeVerifier) VerifyProviderContext(ctx context.Context, request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+	log.Println("[DEBUG] native verifier: verifying a provider")
+	var response types.ProviderVerifierResponse
+
+	if v.Handler != nil && request.ProviderBaseURL == "" {
+		request.ProviderBaseURL = "http://localhost"
+	}
+
+	err := request.Validate()
+	if err != nil {
+		return response, err
+	}
+
+	filter, err := newInteractionFilter(request)
+	if err != nil {
+		return response, err
+	}
+
+	if v.Handler == nil {
+		err = waitForPort(getPort(request.ProviderBaseURL), v.network(), getAddress(request.ProviderBaseURL), v.timeout(),
+			fmt.Sprintf(`Timed out waiting for Provider API to start on %s - are you sure it's running?`, request.ProviderBaseURL))
+		if err != nil {
+			return response, err
+		}
+	}
+
+	start := time.Now()
+	for _, pactURL := range request.PactURLs {
+		pact, err := loadPactFile(ctx, pactURL, request, v.Client)
+		if err != nil {
+			return response, err
+		}
+
+		for i, interaction := range pact.Interactions {
+			if err = ctx.Err(); err != nil {
+				return response, err
+			}
+			if !filter.matches(pact, interaction) {
+				log.Printf("[DEBUG] native verifier: skipping filtered interaction '%s'", interaction.Description)
+				continue
+			}
+
+			example := v.verifyInteraction(pact, interaction, request)
+			example.ID = fmt.Sprintf("%s[%d]", pactURL, i+1)
+			example.FilePath = pactURL
+			response.Examples = append(response.Examples, example)
+		}
+	}
+
+	response.Summary.Duration = time.Since(start).Seconds()
+	response.Summary.ExampleCount = len(response.Examples)
+	for _, example := range response.Examples {
+		if example.Status != "passed" {
+			response.Summary.FailureCount++
+		}
+	}
+	response.SummaryLine = fmt.Sprintf("%d examples, %d failures", response.Summary.ExampleCount, response.Summary.FailureCount)
+	log.Println("[DEBUG] native verifier:", response.SummaryLine)
+
+	if response.Summary.FailureCount > 0 {
+		return response, fmt.Errorf("error verifying provider: %s", response.SummaryLine)
+	}
+
+	return response, nil
+}
+
+// verifyInteraction sets up the provider states of an interaction, replays
+// its request against the Provider and compares the response, before tearing
+// the states down again. The BeforeEach and AfterEach hooks run around it.
+func (v *NativeVerifier) verifyInteraction(pact *PactFile, interaction *PactInteraction, request types.VerifyRequest) (example types.ProviderVerifierExample) {
+	start := time.Now()
+	example = types.ProviderVerifierExample{
+		Description:     interaction.Description,
+		FullDescription: fullDescription(pact, interaction),
+		Status:          "passed",
+	}
+	log.Println("[DEBUG] native verifier: verifying interaction:", example.FullDescription)
+
+	fail := func(class string, message string) types.ProviderVerifierExample {
+		log.Printf("[DEBUG] native verifier: interaction '%s' failed: %s", interaction.Description, message)
+		example.Status = "failed"
+		example.Exception.Class = class
+		example.Exception.Message = message
+		example.RunTime = time.Since(start).Seconds()
+		return example
+	}
+
+	if request.BeforeEach != nil {
+		if err := request.BeforeEach(); err != nil {
+			return fail("HookError", fmt.Sprintf("BeforeEach hook failed: %v", err))
+		}
+	}
+
+	// The AfterEach hook runs last, after the provider states are torn down
+	if request.AfterEach != nil {
+		defer func() {
+			if err := request.AfterEach(); err != nil {
+				message := fmt.Sprintf("AfterEach hook failed: %v", err)
+				if example.Status == "passed" {
+					example = fail("HookError", message)
+					return
+				}
+				example.Exception.Message = fmt.Sprintf("%s\n%s", example.Exception.Message, message)
+			}
+		}()
+	}
+
+	states, err := v.setupStates(pact, interaction, request)
+
+	// Provider states are torn down after every interaction, whether or not
+	// it passed, with a failure to do so reported against the interaction
+	defer func() {
+		if err := v.teardownStates(pact, states, request); err != nil {
+			if example.Status == "passed" {
+				example = fail("ProviderStateError", err.Error())
+				return
+			}
+			example.Exception.Message = fmt.Sprintf("%s\n%s", example.Exception.Message, err)
+		}
+	}()
+
+	if err != nil {
+		return fail("ProviderStateError", err.Error())
+	}
+
+	res, body, err := v.replay(interaction.Request, request)
+	if err != nil {
+		return fail("RequestError", err.Error())
+	}
+
+	if mismatches := interaction.Response.match(res.StatusCode, res.Header, body); len(mismatches) > 0 {
+		messages := make([]string, len(mismatches))
+		for i, mismatch := range mismatches {
+			messages[i] = mismatch.Message
+			example.Mismatches = append(example.Mismatches, types.ProviderVerifierMismatch{
+				Path:     mismatch.Path,
+				Expected: mismatch.Expected,
+				Actual:   mismatch.Actual,
+				Message:  mismatch.Message,
+			})
+		}
+		example.Expected = &types.ProviderVerifierHTTPResponse{
+			Status:  interaction.Response.Status,
+			Headers: interaction.Response.Headers,
+			Body:    interaction.Response.Body,
+		}
+		example.Actual = actualResponse(res, body)
+		return fail("Mismatch", strings.Join(messages, "\n"))
+	}
+
+	example.RunTime = time.Since(start).Seconds()
+	return example
+}
+
+// actualResponse describes a response of the Provider, with its body parsed
+// if it is JSON.
+func actualResponse(res *http.Response, body []byte) *types.ProviderVerifierHTTPResponse {
+	actual := &types.ProviderVerifierHTTPResponse{
+		Status:  res.StatusCode,
+		Headers: make(map[string]string, len(res.Header)),
+	}
+	for key, values := range res.Header {
+		actual.Headers[key] = strings.Join(values, ", ")
+	}
+
+	if len(body) > 0 {
+		var parsed interface{}
+		if isJSONContentType(res.Header.Get("Content-Type")) && json.Unmarshal(body, &parsed) == nil {
+			actual.Body = parsed
+		} else {
+			actual.Body = string(body)
+		}
+	}
+
+	return actual
+}
+
+// setupStates sets up each of the provider states of an interaction, either
+// with the state handlers or by calling the provider states setup URL. It
+// returns the states that were set up, which need to be torn down even if
+// the setup of a later state failed.
+func (v *NativeVerifier) setupStates(pact *PactFile, interaction *PactInteraction, request types.VerifyRequest) ([]State, error) {
+	if len(interaction.ProviderStates) == 0 {
+		return nil, nil
+	}
+
+	if !hasStateHandlers(request) && request.ProviderStatesSetupURL == "" {
+		log.Printf("[WARN] native verifier: no provider states setup URL given, skipping setup of states for '%s'", interaction.Description)
+		return nil, nil
+	}
+
+	var setup []State
+	for _, state := range interaction.ProviderStates {
+		if err := v.changeState(pact, state, StateSetup, request); err != nil {
+			return setup, err
+		}
+		setup = append(setup, state)
+	}
+
+	return setup, nil
+}
+
+// teardownStates tears down provider states in the reverse order to their
+// setup.
+func (v *NativeVerifier) teardownStates(pact *PactFile, states []State, request types.VerifyRequest) error {
+	for i := len(states) - 1; i >= 0; i-- {
+		if err := v.changeState(pact, states[i], StateTeardown, request); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changeState sets up or tears down a provider state, either with its state
+// handler or by calling the provider states setup URL.
+func (v *NativeVerifier) changeState(pact *PactFile, state State, action StateAction, request types.VerifyRequest) error {
+	if hasStateHandlers(request) {
+		return callStateHandler(request.StateHandlers, request.StateLifecycleHandlers, action, state)
+	}
+
+	setupURL := request.ProviderStatesSetupURL
+	if strings.HasPrefix(setupURL, "/") {
+		setupURL = strings.TrimSuffix(request.ProviderBaseURL, "/") + setupURL
+	}
+
+	data, err := json.Marshal(types.ProviderState{
+		Consumer: pact.Consumer.Name,
+		State:    state.Name,
+		States:   []string{state.Name},
+		Params:   state.Params,
+		Action:   action,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, setupURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setCustomHeaders(req, request.CustomProviderHeaders)
+
+	res, err := v.providerClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to %s provider state '%s': %v", stateVerb(action), state.Name, err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unable to %s provider state '%s': received status %d from %s: %s", stateVerb(action), state.Name, res.StatusCode, setupURL, body)
+	}
+
+	return nil
+}
+
+// stateVerb describes a state action for error messages e.g. "set up".
+func stateVerb(action StateAction) string {
+	if action == StateTeardown {
+		return "tear down"
+	}
+	return "set up"
+}
+
+// replay sends the expected request of an interaction to the Provider.
+func (v *NativeVerifier) replay(expected PactRequest, request types.VerifyRequest) (*http.Response, []byte, error) {
+	url := strings.TrimSuffix(request.ProviderBaseURL, "/") + expected.Path
+	if len(expected.Query) > 0 {
+		url = fmt.Sprintf("%s?%s", url, expected.Query.Encode())
+	}
+
+	var body []byte
+	switch b := expected.Body.(type) {
+	case nil:
+	case string:
+		body = []byte(b)
+	default:
+		var err error
+		if body, err = json.Marshal(b); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	req, err := http.NewRequest(expected.Method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for key, value := range expected.Headers {
+		req.Header.Set(key, value)
+	}
+	if _, ok := expected.Body.(string); !ok && body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	setCustomHeaders(req, request.CustomProviderHeaders)
+	if request.RequestFilter != nil {
+		request.RequestFilter(req)
+	}
+
+	log.Printf("[DEBUG] native verifier: replaying request %s %s", req.Method, req.URL)
+	res, err := v.providerClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+
+	return res, data, err
+}
+
+// loadPactFile reads a Pact file from disk, or fetches it from a URL using
+// the broker credentials and transport of the request. The HTTP client is
+// used if the transport does not need one of its own, and may be nil.
+func loadPactFile(ctx context.Context, pactURL string, request types.VerifyRequest, httpClient *http.Client) (*PactFile, error) {
+	if !isURL(pactURL) {
+		return ReadPactFile(pactURL)
+	}
+
+	client, err := verificationBrokerClient(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if client.HTTPClient == nil {
+		client.HTTPClient = httpClient
+	}
+
+	_, data, err := client.Pact(pactURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pact file %s: %v", pactURL, err)
+	}
+
+	var pact PactFile
+	if err = json.Unmarshal(data, &pact); err != nil {
+		return nil, fmt.Errorf("unable to parse pact file %s: %v", pactURL, err)
+	}
+
+	return &pact, nil
+}
+
+func (v *NativeVerifier) client() *http.Client {
+	if v.Client == nil {
+		return http.DefaultClient
+	}
+	return v.Client
+}
+
+// providerClient is the client used to communicate with the Provider, which
+// serves requests with the Handler if one is given.
+func (v *NativeVerifier) providerClient() *http.Client {
+	if v.Handler == nil {
+		return v.client()
+	}
+
+	return &http.Client{
+		Transport: handlerTransport{handler: v.Handler},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (v *NativeVerifier) network() string {
+	if v.Network == "" {
+		return "tcp"
+	}
+	return v.Network
+}
+
+func (v *NativeVerifier) timeout() time.Duration {
+	if v.Timeout == 0 {
+		return 10 * time.Second
+	}
+	return v.Timeout
+}
+
+// handlerTransport is an http.RoundTripper that serves requests in-process
+// with an http.Handler, rather than sending them over the network.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip serves the request with the handler and returns its response.
+func (h handlerTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	body := req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+
+	serverReq, err := http.NewRequest(req.Method, req.URL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	serverReq = serverReq.WithContext(req.Context())
+
+	// Fill in the fields of a request received by a server, as
+	// httptest.NewRequest does
+	serverReq.RequestURI = req.URL.RequestURI()
+	serverReq.RemoteAddr = "192.0.2.1:1234"
+	serverReq.Header = make(http.Header, len(req.Header))
+	for key, values := range req.Header {
+		serverReq.Header[key] = append([]string(nil), values...)
+	}
+	serverReq.ContentLength = req.ContentLength
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("provider panicked serving %s %s: %v", req.Method, req.URL, r)
+		}
+	}()
+
+	recorder := httptest.NewRecorder()
+	h.handler.ServeHTTP(recorder, serverReq)
+
+	res = recorder.Result()
+	res.Request = req
+
+	return res, nil
+}
+
+// setCustomHeaders adds headers in the form "Name: value" to a request.
+func setCustomHeaders(req *http.Request, headers []string) {
+	for _, header := range headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 {
+			log.Printf("[WARN] native verifier: ignoring invalid custom provider header '%s'", header)
+			continue
+		}
+		req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+}
+
+// fullDescription describes an interaction in the same way as the Ruby
+// verifier e.g. "Verifying a pact between billy and bobby Given User billy
+// exists A request for billy with GET /users/1".
+func fullDescription(pact *PactFile, interaction *PactInteraction) string {
+	description := fmt.Sprintf("Verifying a pact between %s and %s", pact.Consumer.Name, pact.Provider.Name)
+	for _, state := range interaction.ProviderStates {
+		description = fmt.Sprintf("%s Given %s", description, state.Name)
+	}
+
+	return fmt.Sprintf("%s %s with %s", description, interaction.Description, interaction.Request)
+}
@@ -0,0 +1,775 @@
+package dsl
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// setupProvider starts a Provider API for billy, which only exists once the
+// "User billy exists" state has been set up.
+func setupProvider() (*httptest.Server, *[]types.ProviderState) {
+	handler, states := providerHandler()
+	return httptest.NewServer(handler), states
+}
+
+func providerHandler() (http.Handler, *[]types.ProviderState) {
+	var states []types.ProviderState
+	exists := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/setup", func(w http.ResponseWriter, req *http.Request) {
+		var s types.ProviderState
+		json.NewDecoder(req.Body).Decode(&s)
+		if s.Action == types.StateTeardown {
+			exists = false
+			return
+		}
+		states = append(states, s)
+		exists = s.State == "User billy exists"
+	})
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, req *http.Request) {
+		if !exists || req.Header.Get("Authorization") != "Bearer 1234" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name":"sally","id":22}`)
+	})
+
+	return mux, &states
+}
+
+func writeUserPact(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	i, err := newPactInteraction(mustMarshal(t, userInteraction()))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	pact := &PactFile{
+		Consumer:     PactName{Name: "billy"},
+		Provider:     PactName{Name: "bobby"},
+		Interactions: []*PactInteraction{i},
+	}
+	if err = WritePactFile(pact, dir, ""); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return filepath.Join(dir, "billy-bobby.json")
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	return data
+}
+
+func TestNativeVerifier_VerifyProvider(t *testing.T) {
+	provider, states := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 || res.Examples[0].Status != "passed" || res.Summary.ExampleCount != 1 || res.Summary.FailureCount != 0 {
+		t.Fatalf("expected one passing example, got %+v", res)
+	}
+
+	if want := "Verifying a pact between billy and bobby Given User billy exists A request for billy with GET /users/1"; res.Examples[0].FullDescription != want {
+		t.Fatalf("want full description %q, got %q", want, res.Examples[0].FullDescription)
+	}
+
+	if len(*states) != 1 || (*states)[0].Consumer != "billy" || (*states)[0].State != "User billy exists" {
+		t.Fatalf("expected the provider state to be set up, got %v", *states)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderFail(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL: provider.URL,
+		PactURLs:        []string{file},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if len(res.Examples) != 1 || res.Examples[0].Status != "failed" || res.Summary.FailureCount != 1 {
+		t.Fatalf("expected one failing example, got %+v", res)
+	}
+
+	if !strings.Contains(res.Examples[0].Exception.Message, "Expected status 200 but received 404") {
+		t.Fatalf("expected a status mismatch, got %s", res.Examples[0].Exception.Message)
+	}
+
+	example := res.Examples[0]
+	if example.Expected == nil || example.Expected.Status != 200 || example.Actual == nil || example.Actual.Status != 404 {
+		t.Fatalf("expected the expected and actual responses, got %+v and %+v", example.Expected, example.Actual)
+	}
+	if len(example.Mismatches) == 0 || example.Mismatches[0].Path != "$.status" {
+		t.Fatalf("expected a status mismatch, got %+v", example.Mismatches)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderStateFail(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	res, _ := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: provider.URL + "/missing",
+	})
+
+	if len(res.Examples) != 1 || res.Examples[0].Exception.Class != "ProviderStateError" {
+		t.Fatalf("expected a provider state failure, got %+v", res)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderFromBroker(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, pass, _ := req.BasicAuth()
+		if (user != "foo" || pass != "bar") && req.Header.Get("Authorization") != "Bearer abcd" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeFile(w, req, file)
+	}))
+	defer broker.Close()
+
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{broker.URL + "/pacts/provider/bobby/consumer/billy/latest"},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+		BrokerUsername:         "foo",
+		BrokerPassword:         "bar",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 {
+		t.Fatalf("expected one example, got %+v", res)
+	}
+
+	res, err = verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{broker.URL + "/pacts/provider/bobby/consumer/billy/latest"},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+		BrokerToken:            "abcd",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 {
+		t.Fatalf("expected one example, got %+v", res)
+	}
+
+	_, err = verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL: provider.URL,
+		PactURLs:        []string{broker.URL + "/pacts/provider/bobby/consumer/billy/latest"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Fatalf("expected an unauthorised error, got %v", err)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderFromBrokerWithTLS(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	broker := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.ServeFile(w, req, file)
+	}))
+	defer broker.Close()
+
+	caFile := filepath.Join(filepath.Dir(file), "ca.pem")
+	ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: broker.Certificate().Raw}), 0644)
+
+	verifier := &NativeVerifier{}
+	request := types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{broker.URL + "/pacts/provider/bobby/consumer/billy/latest"},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	}
+	if _, err := verifier.VerifyProvider(request); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	request.BrokerTransport = types.BrokerTransport{CACertFile: caFile}
+	res, err := verifier.VerifyProvider(request)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 {
+		t.Fatalf("expected one example, got %+v", res)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderInvalidRequest(t *testing.T) {
+	verifier := &NativeVerifier{}
+	if _, err := verifier.VerifyProvider(types.VerifyRequest{}); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestPact_NativeProviderVerifier(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	pact := &Pact{
+		Provider:               "bobby",
+		LogLevel:               "DEBUG",
+		NativeProviderVerifier: true,
+	}
+
+	_, err := pact.VerifyProvider(t, types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderHandler(t *testing.T) {
+	handler, states := providerHandler()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{Handler: handler}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 || res.Examples[0].Status != "passed" || len(*states) != 1 {
+		t.Fatalf("expected one passing example, got %+v", res)
+	}
+}
+
+func TestNativeVerifier_VerifyProviderHandlerPanic(t *testing.T) {
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		PactURLs: []string{file},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if len(res.Examples) != 1 || !strings.Contains(res.Examples[0].Exception.Message, "boom") {
+		t.Fatalf("expected the panic to be reported, got %+v", res)
+	}
+}
+
+func Test_handlerTransportInvalidRequest(t *testing.T) {
+	transport := handlerTransport{handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})}
+	req, _ := http.NewRequest("GET", "http://localhost/users/22", nil)
+	req.Method = "BAD METHOD"
+
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestPact_VerifyProviderHandler(t *testing.T) {
+	handler, _ := providerHandler()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	pact := &Pact{
+		Provider: "bobby",
+		LogLevel: "DEBUG",
+	}
+
+	_, err := pact.VerifyProviderHandler(t, handler, types.VerifyRequest{
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func TestNativeVerifier_ProviderStateParams(t *testing.T) {
+	provider, states := setupProvider()
+	defer provider.Close()
+
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	interaction := userInteraction()
+	interaction.States[0].Params = map[string]interface{}{"id": 22}
+	i, err := newPactInteraction(mustMarshal(t, interaction))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	pact := &PactFile{
+		Consumer:     PactName{Name: "billy"},
+		Provider:     PactName{Name: "bobby"},
+		Interactions: []*PactInteraction{i},
+	}
+	pact.SetSpecificationVersion(3)
+	if err = WritePactFile(pact, dir, ""); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	verifier := &NativeVerifier{}
+	_, err = verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{filepath.Join(dir, "billy-bobby.json")},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		CustomProviderHeaders:  []string{"Authorization: Bearer 1234"},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(*states) != 1 || (*states)[0].Params["id"] != 22.0 {
+		t.Fatalf("expected the provider state params to be sent, got %v", *states)
+	}
+}
+
+func TestNativeVerifier_StateHandlers(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var called []State
+	verifier := &NativeVerifier{}
+	_, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:       provider.URL,
+		PactURLs:              []string{file},
+		CustomProviderHeaders: []string{"Authorization: Bearer 1234"},
+		StateHandlers: StateHandlers{
+			"User billy exists": func(s State) error {
+				called = append(called, s)
+				_, err := http.Post(provider.URL+"/setup", "application/json", strings.NewReader(`{"state":"User billy exists"}`))
+				return err
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(called) != 1 || called[0].Name != "User billy exists" {
+		t.Fatalf("expected the state handler to be called, got %v", called)
+	}
+}
+
+func TestNativeVerifier_StateHandlersMissing(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL: provider.URL,
+		PactURLs:        []string{file},
+		StateHandlers: StateHandlers{
+			"Some other state": func(s State) error { return nil },
+		},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if len(res.Examples) != 1 || res.Examples[0].Exception.Class != "ProviderStateError" ||
+		!strings.Contains(res.Examples[0].Exception.Message, "no state handler found for state: User billy exists") {
+		t.Fatalf("expected a missing state handler failure, got %+v", res)
+	}
+}
+
+func TestPact_StateHandler(t *testing.T) {
+	var called State
+	handler := stateHandler(StateHandlers{
+		"User billy exists": func(s State) error {
+			called = s
+			return nil
+		},
+		"User sally exists": func(s State) error {
+			return fmt.Errorf("sally is unavailable")
+		},
+	}, nil)
+
+	post := func(state types.ProviderState) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(mustMarshal(t, state))))
+		handler(recorder, req)
+		return recorder.Code
+	}
+
+	if code := post(types.ProviderState{State: "User billy exists", Params: map[string]interface{}{"id": "1"}}); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if called.Name != "User billy exists" || called.Params["id"] != "1" {
+		t.Fatalf("expected the state handler to be called with params, got %v", called)
+	}
+	if code := post(types.ProviderState{State: "User sally exists"}); code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 for a failing handler, got %d", code)
+	}
+	if code := post(types.ProviderState{State: "Unknown"}); code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 for a missing handler, got %d", code)
+	}
+}
+
+func TestPact_StateHandlersWithSetupURL(t *testing.T) {
+	pact := &Pact{NativeProviderVerifier: true}
+	_, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL:        "http://localhost:1234",
+		PactURLs:               []string{"billy-bobby.json"},
+		ProviderStatesSetupURL: "http://localhost:1234/setup",
+		StateHandlers: StateHandlers{
+			"User billy exists": func(s State) error { return nil },
+		},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestNativeVerifier_StateLifecycleHandlers(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var actions []StateAction
+	verifier := &NativeVerifier{}
+	_, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:       provider.URL,
+		PactURLs:              []string{file},
+		CustomProviderHeaders: []string{"Authorization: Bearer 1234"},
+		StateLifecycleHandlers: StateLifecycleHandlers{
+			"User billy exists": func(action StateAction, s State) error {
+				actions = append(actions, action)
+				_, err := http.Post(provider.URL+"/setup", "application/json",
+					strings.NewReader(fmt.Sprintf(`{"state":"User billy exists","action":"%s"}`, action)))
+				return err
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(actions) != 2 || actions[0] != StateSetup || actions[1] != StateTeardown {
+		t.Fatalf("expected the state to be set up and torn down, got %v", actions)
+	}
+}
+
+func TestNativeVerifier_StateTeardownAfterFailure(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var actions []StateAction
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL: provider.URL,
+		PactURLs:        []string{file},
+		StateLifecycleHandlers: StateLifecycleHandlers{
+			"User billy exists": func(action StateAction, s State) error {
+				actions = append(actions, action)
+				return nil
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if res.Examples[0].Exception.Class != "Mismatch" {
+		t.Fatalf("expected a mismatch, got %+v", res.Examples[0])
+	}
+	if len(actions) != 2 || actions[1] != StateTeardown {
+		t.Fatalf("expected the state to be torn down after a failure, got %v", actions)
+	}
+}
+
+func TestNativeVerifier_StateTeardownFail(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	res, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:       provider.URL,
+		PactURLs:              []string{file},
+		CustomProviderHeaders: []string{"Authorization: Bearer 1234"},
+		StateLifecycleHandlers: StateLifecycleHandlers{
+			"User billy exists": func(action StateAction, s State) error {
+				if action == StateTeardown {
+					return fmt.Errorf("unable to delete billy")
+				}
+				_, err := http.Post(provider.URL+"/setup", "application/json", strings.NewReader(`{"state":"User billy exists"}`))
+				return err
+			},
+		},
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if len(res.Examples) != 1 || res.Examples[0].Exception.Class != "ProviderStateError" ||
+		!strings.Contains(res.Examples[0].Exception.Message, "unable to delete billy") {
+		t.Fatalf("expected a provider state teardown failure, got %+v", res)
+	}
+}
+
+func TestNativeVerifier_StateTeardownURL(t *testing.T) {
+	var actions []StateAction
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/setup" {
+			var s types.ProviderState
+			json.NewDecoder(req.Body).Decode(&s)
+			actions = append(actions, s.Action)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	verifier := &NativeVerifier{}
+	verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+	})
+
+	if len(actions) != 2 || actions[0] != StateSetup || actions[1] != StateTeardown {
+		t.Fatalf("expected setup and teardown to be posted, got %v", actions)
+	}
+}
+
+func TestPact_StateHandlerTeardown(t *testing.T) {
+	var actions []StateAction
+	handler := stateHandler(StateHandlers{
+		"User billy exists": func(s State) error {
+			actions = append(actions, StateSetup)
+			return nil
+		},
+	}, StateLifecycleHandlers{
+		"User sally exists": func(action StateAction, s State) error {
+			actions = append(actions, action)
+			return nil
+		},
+	})
+
+	for _, state := range []types.ProviderState{
+		{State: "User billy exists", Action: StateSetup},
+		{State: "User billy exists", Action: StateTeardown},
+		{State: "User sally exists"},
+		{State: "User sally exists", Action: StateTeardown},
+	} {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(mustMarshal(t, state)))))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", recorder.Code)
+		}
+	}
+
+	expected := []StateAction{StateSetup, StateSetup, StateTeardown}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("expected actions %v, got %v", expected, actions)
+	}
+}
+
+func TestPact_MessageHandlerStateTeardown(t *testing.T) {
+	var calls []string
+	handler := messageHandler(MessageHandlers{
+		"a user event": func(m Message) (interface{}, error) {
+			calls = append(calls, "message")
+			return map[string]string{"name": "billy"}, nil
+		},
+	}, nil, StateLifecycleHandlers{
+		"User billy exists": func(action StateAction, s State) error {
+			calls = append(calls, string(action))
+			return nil
+		},
+	})
+
+	message := `{"description":"a user event","providerStates":[{"name":"User billy exists"}]}`
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(message)))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	expected := []string{"setup", "message", "teardown"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestNativeVerifier_Hooks(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	var calls []string
+	verifier := &NativeVerifier{}
+	_, err := verifier.VerifyProvider(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		PactURLs:               []string{file},
+		ProviderStatesSetupURL: provider.URL + "/setup",
+		BeforeEach: func() error {
+			calls = append(calls, "before")
+			return nil
+		},
+		AfterEach: func() error {
+			calls = append(calls, "after")
+			return nil
+		},
+		RequestFilter: func(req *http.Request) {
+			calls = append(calls, "filter")
+			req.Header.Set("Authorization", "Bearer 1234")
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	expected := []string{"before", "filter", "after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestNativeVerifier_HookFail(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+
+	for name, request := range map[string]types.VerifyRequest{
+		"BeforeEach": {BeforeEach: func() error { return fmt.Errorf("cache unavailable") }},
+		"AfterEach":  {AfterEach: func() error { return fmt.Errorf("cache unavailable") }},
+	} {
+		request.ProviderBaseURL = provider.URL
+		request.PactURLs = []string{file}
+		request.ProviderStatesSetupURL = provider.URL + "/setup"
+		request.CustomProviderHeaders = []string{"Authorization: Bearer 1234"}
+
+		verifier := &NativeVerifier{}
+		res, err := verifier.VerifyProvider(request)
+		if err == nil {
+			t.Fatalf("Expected error but got none")
+		}
+
+		if len(res.Examples) != 1 || res.Examples[0].Exception.Class != "HookError" ||
+			res.Examples[0].Exception.Message != name+" hook failed: cache unavailable" {
+			t.Fatalf("expected a %s hook failure, got %+v", name, res)
+		}
+	}
+}
+
+func TestPact_NativeProviderVerifierPending(t *testing.T) {
+	provider, _ := setupProvider()
+	defer provider.Close()
+	file := writeUserPact(t)
+	defer os.RemoveAll(filepath.Dir(file))
+	pactFile, _ := ioutil.ReadFile(file)
+
+	var broker *httptest.Server
+	broker = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/pacts/provider/bobby/for-verification":
+			fmt.Fprintf(w, `{"_embedded":{"pacts":[{"verificationProperties":{"pending":true},"_links":{"self":{"href":"%s/pacts/1"}}}]}}`, broker.URL)
+		case "/pacts/1":
+			w.Write(pactFile)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer broker.Close()
+
+	// Without the Authorization header, the interaction fails
+	pact := &Pact{Provider: "bobby", NativeProviderVerifier: true, LogLevel: "DEBUG"}
+	res, err := pact.VerifyProviderRaw(types.VerifyRequest{
+		ProviderBaseURL:        provider.URL,
+		BrokerURL:              broker.URL,
+		EnablePending:          true,
+		ProviderStatesSetupURL: provider.URL + "/setup",
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(res.Examples) != 1 || !res.Examples[0].Pending || res.Examples[0].Status != "failed" || res.Summary.PendingCount != 1 {
+		t.Fatalf("expected a failed pending interaction, got %+v", res)
+	}
+	if res.SummaryLine != "1 examples, 0 failures, 1 pending" {
+		t.Fatalf("expected a pending summary, got %s", res.SummaryLine)
+	}
+}
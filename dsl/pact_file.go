@@ -0,0 +1,727 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PactFile is a representation of a Pact file, supporting both v2 and v3 of
+// the Pact Specification. The specification version recorded in the Metadata
+// determines the format the file is written in.
+type PactFile struct {
+	// The API Consumer name
+	Consumer PactName `json:"consumer"`
+
+	// The API Provider name
+	Provider PactName `json:"provider"`
+
+	// Interactions are the HTTP interactions between the Consumer and Provider.
+	Interactions []*PactInteraction `json:"interactions,omitempty"`
+
+	// Messages are the asynchronous interactions between the Consumer and Provider.
+	Messages []*PactMessage `json:"messages,omitempty"`
+
+	// Metadata about the Pact file, such as the specification version.
+	Metadata PactMetadata `json:"metadata"`
+}
+
+// PactName represents the name fields in the PactFile.
+type PactName struct {
+	Name string `json:"name"`
+}
+
+// PactMetadata contains the metadata section of a Pact file.
+type PactMetadata struct {
+	PactSpecification PactSpecification `json:"pactSpecification"`
+}
+
+// PactSpecification records the version of the Pact Specification a Pact
+// file conforms to.
+type PactSpecification struct {
+	Version string `json:"version"`
+}
+
+// PactInteraction is an HTTP interaction in the form it is written to a pact
+// file: any matchers have been replaced by their example values, with the
+// matching rules recorded alongside the request and response.
+type PactInteraction struct {
+	// Description of the interaction.
+	Description string
+
+	// ProviderStates the Provider must be in for the interaction. Only the
+	// last state is written to a v2 Pact file.
+	ProviderStates []State
+
+	// Request expected by the Provider.
+	Request PactRequest
+
+	// Response expected from the Provider.
+	Response PactResponse
+}
+
+// PactRequest is the expected request of a PactInteraction.
+type PactRequest struct {
+	Method        string
+	Path          string
+	Query         url.Values
+	Headers       map[string]string
+	Body          interface{}
+	MatchingRules MatchingRules
+	Generators    Generators
+}
+
+// PactResponse is the expected response of a PactInteraction.
+type PactResponse struct {
+	Status        int
+	Headers       map[string]string
+	Body          interface{}
+	MatchingRules MatchingRules
+	Generators    Generators
+}
+
+// PactMessage is an asynchronous message in the form it is written to a
+// pact file.
+type PactMessage struct {
+	// Description of the message.
+	Description string
+
+	// ProviderStates the Provider must be in to produce the message.
+	ProviderStates []State
+
+	// Contents of the message.
+	Contents interface{}
+
+	// Metadata is implementation specific data sent with the message.
+	Metadata map[string]interface{}
+
+	// MatchingRules for the contents of the message.
+	MatchingRules MatchingRules
+
+	// Generators for the contents of the message.
+	Generators Generators
+}
+
+// Generator describes how a value should be generated when an interaction is
+// replayed, e.g. a random integer or the current date.
+type Generator struct {
+	Type       string `json:"type"`
+	Min        *int   `json:"min,omitempty"`
+	Max        *int   `json:"max,omitempty"`
+	Digits     *int   `json:"digits,omitempty"`
+	Size       *int   `json:"size,omitempty"`
+	Regex      string `json:"regex,omitempty"`
+	Format     string `json:"format,omitempty"`
+	Expression string `json:"expression,omitempty"`
+}
+
+// Generators maps a v2 style path expression, as used by MatchingRules, to
+// the generator for that path.
+type Generators map[string]Generator
+
+// SpecificationVersion returns the major version of the Pact Specification
+// the file conforms to. Defaults to 2.
+func (p *PactFile) SpecificationVersion() int {
+	version := strings.SplitN(p.Metadata.PactSpecification.Version, ".", 2)[0]
+	if v, err := strconv.Atoi(version); err == nil && v > 0 {
+		return v
+	}
+
+	return 2
+}
+
+// SetSpecificationVersion sets the major version of the Pact Specification
+// the file will be written as, 1, 2 or 3. Defaults to 2.
+func (p *PactFile) SetSpecificationVersion(version int) {
+	if version < 1 {
+		version = 2
+	}
+	p.Metadata.PactSpecification.Version = fmt.Sprintf("%d.0.0", version)
+}
+
+// key uniquely identifies an interaction within a pact file.
+func (i *PactInteraction) key() string {
+	return statesKey(i.ProviderStates) + "\x00" + i.Description
+}
+
+// key uniquely identifies a message within a pact file.
+func (m *PactMessage) key() string {
+	return statesKey(m.ProviderStates) + "\x00" + m.Description
+}
+
+func statesKey(states []State) string {
+	names := make([]string, len(states))
+	for i, s := range states {
+		names[i] = s.Name
+	}
+
+	return strings.Join(names, "\x00")
+}
+
+// MarshalJSON writes the Pact file in the format of its specification version.
+func (p *PactFile) MarshalJSON() ([]byte, error) {
+	version := p.SpecificationVersion()
+	metadata := p.Metadata
+	metadata.PactSpecification.Version = fmt.Sprintf("%d.0.0", version)
+
+	interactions := make([]interface{}, len(p.Interactions))
+	for i, interaction := range p.Interactions {
+		interactions[i] = interaction.toJSON(version)
+	}
+
+	pact := map[string]interface{}{
+		"consumer":     p.Consumer,
+		"provider":     p.Provider,
+		"interactions": interactions,
+		"metadata":     metadata,
+	}
+
+	if len(p.Messages) > 0 {
+		messages := make([]interface{}, len(p.Messages))
+		for i, message := range p.Messages {
+			messages[i] = message.toJSON(version)
+		}
+		pact["messages"] = messages
+
+		if len(p.Interactions) == 0 {
+			delete(pact, "interactions")
+		}
+	}
+
+	return json.Marshal(pact)
+}
+
+func (i *PactInteraction) toJSON(version int) map[string]interface{} {
+	request := map[string]interface{}{
+		"method": i.Request.Method,
+		"path":   i.Request.Path,
+	}
+	if len(i.Request.Query) > 0 {
+		if version >= 3 {
+			request["query"] = i.Request.Query
+		} else {
+			request["query"] = i.Request.Query.Encode()
+		}
+	}
+	if len(i.Request.Headers) > 0 {
+		request["headers"] = i.Request.Headers
+	}
+	if i.Request.Body != nil {
+		request["body"] = i.Request.Body
+	}
+	addRulesJSON(request, i.Request.MatchingRules, i.Request.Generators, version)
+
+	response := map[string]interface{}{
+		"status": i.Response.Status,
+	}
+	if len(i.Response.Headers) > 0 {
+		response["headers"] = i.Response.Headers
+	}
+	if i.Response.Body != nil {
+		response["body"] = i.Response.Body
+	}
+	addRulesJSON(response, i.Response.MatchingRules, i.Response.Generators, version)
+
+	interaction := map[string]interface{}{
+		"description": i.Description,
+		"request":     request,
+		"response":    response,
+	}
+	addStatesJSON(interaction, i.ProviderStates, version)
+
+	return interaction
+}
+
+func (m *PactMessage) toJSON(version int) map[string]interface{} {
+	message := map[string]interface{}{
+		"description": m.Description,
+		"contents":    m.Contents,
+	}
+	if len(m.Metadata) > 0 {
+		message["metaData"] = m.Metadata
+	}
+	addRulesJSON(message, m.MatchingRules, m.Generators, version)
+	addStatesJSON(message, m.ProviderStates, version)
+
+	return message
+}
+
+func addStatesJSON(m map[string]interface{}, states []State, version int) {
+	if len(states) == 0 {
+		return
+	}
+
+	if version >= 3 {
+		m["providerStates"] = states
+	} else {
+		m["providerState"] = states[len(states)-1].Name
+	}
+}
+
+func addRulesJSON(m map[string]interface{}, rules MatchingRules, generators Generators, version int) {
+	// Matching rules were introduced in v2, so v1 only has the examples
+	if len(rules) > 0 && version >= 2 {
+		if version >= 3 {
+			m["matchingRules"] = rules.toV3()
+		} else {
+			m["matchingRules"] = rules.toV2()
+		}
+	}
+
+	// Generators are not part of the v2 specification
+	if len(generators) > 0 && version >= 3 {
+		m["generators"] = generators.toV3()
+	}
+}
+
+// toV2 flattens the rules into the v2 format, where each path has a single
+// rule. Rules introduced in v3 are converted to the closest v2 rule.
+func (m MatchingRules) toV2() map[string]MatchingRule {
+	v2 := make(map[string]MatchingRule, len(m))
+	for path, rules := range m {
+		var merged MatchingRule
+		for _, rule := range rules {
+			rule = rule.toV2(path)
+			if merged.Match == "" {
+				merged = rule
+			}
+			if rule.Min != nil {
+				merged.Min = rule.Min
+			}
+			if rule.Max != nil {
+				merged.Max = rule.Max
+			}
+		}
+		if merged.Match != "" {
+			v2[path] = merged
+		}
+	}
+
+	return v2
+}
+
+// toV2 converts a rule to the closest rule supported by v2 of the Pact
+// Specification, or an empty rule if there is none.
+func (r MatchingRule) toV2(path string) MatchingRule {
+	switch r.Match {
+	case "type", "regex":
+		return r
+	case "include":
+		log.Printf("[WARN] pact file: converting the 'include' rule at %s to a regex for v2 of the Pact Specification", path)
+		return MatchingRule{Match: "regex", Regex: regexp.QuoteMeta(r.Value)}
+	case "null", "equality":
+		log.Printf("[WARN] pact file: the '%s' rule at %s is not supported by v2 of the Pact Specification and will be ignored", r.Match, path)
+		return MatchingRule{}
+	}
+
+	log.Printf("[WARN] pact file: converting the '%s' rule at %s to a type match for v2 of the Pact Specification", r.Match, path)
+	return MatchingRule{Match: "type", Min: r.Min, Max: r.Max}
+}
+
+// toV3 groups the rules by category, as per the v3 format.
+func (m MatchingRules) toV3() map[string]interface{} {
+	v3 := make(map[string]interface{})
+	for path, rules := range m {
+		category, key := splitCategory(path)
+		matchers := map[string]interface{}{
+			"combine":  "AND",
+			"matchers": rules,
+		}
+
+		if key == "" {
+			v3[category] = matchers
+			continue
+		}
+
+		if _, ok := v3[category]; !ok {
+			v3[category] = make(map[string]interface{})
+		}
+		v3[category].(map[string]interface{})[key] = matchers
+	}
+
+	return v3
+}
+
+// toV3 groups the generators by category, as per the v3 format.
+func (g Generators) toV3() map[string]interface{} {
+	v3 := make(map[string]interface{})
+	for path, generator := range g {
+		category, key := splitCategory(path)
+		if key == "" {
+			v3[category] = generator
+			continue
+		}
+
+		if _, ok := v3[category]; !ok {
+			v3[category] = make(map[string]interface{})
+		}
+		v3[category].(map[string]interface{})[key] = generator
+	}
+
+	return v3
+}
+
+// splitCategory converts a v2 style path expression into a v3 category and
+// key, e.g. "$.body.name" becomes "body" and "$.name", and
+// "$.headers.Accept" becomes "header" and "Accept".
+func splitCategory(path string) (string, string) {
+	tokens := parsePath(path)
+	if len(tokens) < 2 {
+		return "body", path
+	}
+
+	switch tokens[1] {
+	case "headers", "header":
+		if len(tokens) > 2 {
+			return "header", tokens[2]
+		}
+		return "header", ""
+	case "query":
+		if len(tokens) > 2 {
+			return "query", tokens[2]
+		}
+		return "query", ""
+	case "path":
+		return "path", ""
+	case "body":
+		return "body", formatPath(append([]string{"$"}, tokens[2:]...))
+	}
+
+	return tokens[1], formatPath(append([]string{"$"}, tokens[2:]...))
+}
+
+// joinCategory is the inverse of splitCategory.
+func joinCategory(category string, key string) string {
+	switch category {
+	case "header", "headers":
+		return formatPath([]string{"$", "headers", key})
+	case "query":
+		return formatPath([]string{"$", "query", key})
+	case "path":
+		return "$.path"
+	}
+
+	tokens := parsePath(key)
+	if len(tokens) == 0 {
+		tokens = []string{"$"}
+	}
+
+	return formatPath(append([]string{"$", category}, tokens[1:]...))
+}
+
+// UnmarshalJSON reads the v2 or v3 representation of matching rules.
+func (m *MatchingRules) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	rules := make(MatchingRules)
+	for key, value := range raw {
+		// v2 keys are path expressions
+		if strings.HasPrefix(key, "$") {
+			var rule MatchingRule
+			if err := json.Unmarshal(value, &rule); err != nil {
+				return err
+			}
+			rules[normalisePath(key)] = []MatchingRule{rule.normalise()}
+			continue
+		}
+
+		// v3 keys are categories, with the path category holding the rules directly
+		if key == "path" {
+			if err := rules.addV3(joinCategory(key, ""), value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var category map[string]json.RawMessage
+		if err := json.Unmarshal(value, &category); err != nil {
+			return err
+		}
+		for path, value := range category {
+			if err := rules.addV3(joinCategory(key, path), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	*m = rules
+	return nil
+}
+
+func (m MatchingRules) addV3(path string, data []byte) error {
+	var matchers struct {
+		Matchers []MatchingRule `json:"matchers"`
+	}
+	if err := json.Unmarshal(data, &matchers); err != nil {
+		return err
+	}
+
+	for _, rule := range matchers.Matchers {
+		m.add(path, rule.normalise())
+	}
+
+	return nil
+}
+
+// normalise fills in the implicit match type of v2 rules.
+func (r MatchingRule) normalise() MatchingRule {
+	if r.Match == "" && r.Regex != "" {
+		r.Match = "regex"
+	}
+	if r.Match == "" {
+		r.Match = "type"
+	}
+
+	return r
+}
+
+// normalisePath converts the alternate forms of v2 paths to those used
+// internally, e.g. "$.header.Accept" becomes "$.headers.Accept".
+func normalisePath(path string) string {
+	tokens := parsePath(path)
+	if len(tokens) > 1 && tokens[1] == "header" {
+		tokens[1] = "headers"
+	}
+
+	return formatPath(tokens)
+}
+
+// UnmarshalJSON reads the v3 representation of generators.
+func (g *Generators) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	generators := make(Generators)
+	for key, value := range raw {
+		if key == "path" {
+			var generator Generator
+			if err := json.Unmarshal(value, &generator); err != nil {
+				return err
+			}
+			generators[joinCategory(key, "")] = generator
+			continue
+		}
+
+		var category map[string]Generator
+		if err := json.Unmarshal(value, &category); err != nil {
+			return err
+		}
+		for path, generator := range category {
+			generators[joinCategory(key, path)] = generator
+		}
+	}
+
+	*g = generators
+	return nil
+}
+
+// UnmarshalJSON reads the v1, v2 or v3 representation of an interaction.
+func (i *PactInteraction) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Description    string          `json:"description"`
+		ProviderState  string          `json:"providerState"`
+		LegacyState    string          `json:"provider_state"`
+		ProviderStates []State         `json:"providerStates"`
+		Request        json.RawMessage `json:"request"`
+		Response       struct {
+			Status        int             `json:"status"`
+			Headers       json.RawMessage `json:"headers"`
+			Body          interface{}     `json:"body"`
+			MatchingRules MatchingRules   `json:"matchingRules"`
+			Generators    Generators      `json:"generators"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var request struct {
+		Method        string          `json:"method"`
+		Path          string          `json:"path"`
+		Query         json.RawMessage `json:"query"`
+		Headers       json.RawMessage `json:"headers"`
+		Body          interface{}     `json:"body"`
+		MatchingRules MatchingRules   `json:"matchingRules"`
+		Generators    Generators      `json:"generators"`
+	}
+	if len(raw.Request) > 0 {
+		if err := json.Unmarshal(raw.Request, &request); err != nil {
+			return err
+		}
+	}
+
+	query, err := unmarshalQuery(request.Query)
+	if err != nil {
+		return err
+	}
+
+	requestHeaders, err := unmarshalHeaders(request.Headers)
+	if err != nil {
+		return err
+	}
+
+	responseHeaders, err := unmarshalHeaders(raw.Response.Headers)
+	if err != nil {
+		return err
+	}
+
+	*i = PactInteraction{
+		Description:    raw.Description,
+		ProviderStates: unmarshalStates(raw.ProviderStates, raw.ProviderState, raw.LegacyState),
+		Request: PactRequest{
+			Method:        strings.ToUpper(request.Method),
+			Path:          request.Path,
+			Query:         query,
+			Headers:       requestHeaders,
+			Body:          request.Body,
+			MatchingRules: request.MatchingRules,
+			Generators:    request.Generators,
+		},
+		Response: PactResponse{
+			Status:        raw.Response.Status,
+			Headers:       responseHeaders,
+			Body:          raw.Response.Body,
+			MatchingRules: raw.Response.MatchingRules,
+			Generators:    raw.Response.Generators,
+		},
+	}
+
+	return nil
+}
+
+// UnmarshalJSON reads the v3 representation of a message.
+func (m *PactMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Description    string                 `json:"description"`
+		ProviderState  string                 `json:"providerState"`
+		ProviderStates []State                `json:"providerStates"`
+		Contents       interface{}            `json:"contents"`
+		MetaData       map[string]interface{} `json:"metaData"`
+		Metadata       map[string]interface{} `json:"metadata"`
+		MatchingRules  MatchingRules          `json:"matchingRules"`
+		Generators     Generators             `json:"generators"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = PactMessage{
+		Description:    raw.Description,
+		ProviderStates: unmarshalStates(raw.ProviderStates, raw.ProviderState, ""),
+		Contents:       raw.Contents,
+		Metadata:       raw.MetaData,
+		MatchingRules:  raw.MatchingRules,
+		Generators:     raw.Generators,
+	}
+	if m.Metadata == nil {
+		m.Metadata = raw.Metadata
+	}
+
+	return nil
+}
+
+// UnmarshalJSON reads the current and legacy forms of the Pact metadata.
+func (m *PactMetadata) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		PactSpecification        PactSpecification `json:"pactSpecification"`
+		LegacySpecification      PactSpecification `json:"pact-specification"`
+		PactSpecificationVersion string            `json:"pactSpecificationVersion"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.PactSpecification = raw.PactSpecification
+	if m.PactSpecification.Version == "" {
+		m.PactSpecification.Version = raw.LegacySpecification.Version
+	}
+	if m.PactSpecification.Version == "" {
+		m.PactSpecification.Version = raw.PactSpecificationVersion
+	}
+
+	return nil
+}
+
+func unmarshalStates(states []State, state string, legacyState string) []State {
+	switch {
+	case len(states) > 0:
+		return states
+	case state != "":
+		return []State{{Name: state}}
+	case legacyState != "":
+		return []State{{Name: legacyState}}
+	}
+
+	return nil
+}
+
+// unmarshalQuery reads a query string (v2) or a map of values (v3).
+func unmarshalQuery(data json.RawMessage) (url.Values, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var query string
+	if err := json.Unmarshal(data, &query); err == nil {
+		if query == "" {
+			return nil, nil
+		}
+		return url.ParseQuery(query)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("unable to parse query: %v", err)
+	}
+
+	result := make(url.Values, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				result.Add(key, fmt.Sprint(item))
+			}
+		default:
+			result.Add(key, fmt.Sprint(v))
+		}
+	}
+
+	return result, nil
+}
+
+// unmarshalHeaders reads headers, joining any multi-valued headers.
+func unmarshalHeaders(data json.RawMessage) (map[string]string, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	var headers map[string]interface{}
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, fmt.Errorf("unable to parse headers: %v", err)
+	}
+
+	result := make(map[string]string, len(headers))
+	for key, value := range headers {
+		switch v := value.(type) {
+		case []interface{}:
+			values := make([]string, len(v))
+			for i, item := range v {
+				values[i] = fmt.Sprint(item)
+			}
+			result[key] = strings.Join(values, ", ")
+		default:
+			result[key] = fmt.Sprint(v)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,263 @@
+package dsl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func samplePactFile(version int) *PactFile {
+	pact := &PactFile{
+		Consumer: PactName{Name: "billy"},
+		Provider: PactName{Name: "bobby"},
+		Interactions: []*PactInteraction{
+			{
+				Description:    "A request for billy",
+				ProviderStates: []State{{Name: "User billy exists", Params: map[string]interface{}{"id": "1"}}},
+				Request: PactRequest{
+					Method:  "GET",
+					Path:    "/users/1",
+					Query:   map[string][]string{"sort": {"asc"}},
+					Headers: map[string]string{"Accept": "application/json"},
+					MatchingRules: MatchingRules{
+						"$.path":           {{Match: "regex", Regex: "/users/\\d+"}},
+						"$.headers.Accept": {{Match: "type"}},
+					},
+				},
+				Response: PactResponse{
+					Status: 200,
+					Body:   map[string]interface{}{"name": "billy"},
+					MatchingRules: MatchingRules{
+						"$.body.name": {{Match: "type"}},
+					},
+					Generators: Generators{
+						"$.body.id": {Type: "RandomInt", Min: intPtr(1), Max: intPtr(10)},
+					},
+				},
+			},
+		},
+	}
+	pact.SetSpecificationVersion(version)
+
+	return pact
+}
+
+func TestPactFile_SpecificationVersion(t *testing.T) {
+	pact := &PactFile{}
+	if v := pact.SpecificationVersion(); v != 2 {
+		t.Fatalf("expected default version 2, got %d", v)
+	}
+
+	pact.SetSpecificationVersion(1)
+	if v := pact.SpecificationVersion(); v != 1 || pact.Metadata.PactSpecification.Version != "1.0.0" {
+		t.Fatalf("expected version 1, got %d (%s)", v, pact.Metadata.PactSpecification.Version)
+	}
+
+	pact.SetSpecificationVersion(3)
+	if v := pact.SpecificationVersion(); v != 3 || pact.Metadata.PactSpecification.Version != "3.0.0" {
+		t.Fatalf("expected version 3, got %d (%s)", v, pact.Metadata.PactSpecification.Version)
+	}
+}
+
+func TestPactFile_MarshalV2(t *testing.T) {
+	data, err := json.Marshal(samplePactFile(2))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"providerState":"User billy exists"`, `"query":"sort=asc"`, `"$.path":{"match":"regex","regex":"/users/\\d+"}`, `"$.body.name":{"match":"type"}`, `"version":"2.0.0"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+
+	if strings.Contains(string(data), "generators") {
+		t.Fatalf("expected no generators in a v2 pact file, got %s", data)
+	}
+}
+
+func TestPactFile_MarshalV1(t *testing.T) {
+	data, err := json.Marshal(samplePactFile(1))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"providerState":"User billy exists"`, `"query":"sort=asc"`, `"version":"1.0.0"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+
+	if strings.Contains(string(data), "matchingRules") || strings.Contains(string(data), "generators") {
+		t.Fatalf("expected no matching rules or generators in a v1 pact file, got %s", data)
+	}
+}
+
+func TestPactFile_MarshalV3(t *testing.T) {
+	data, err := json.Marshal(samplePactFile(3))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"providerStates":[{"name":"User billy exists","params":{"id":"1"}}]`, `"query":{"sort":["asc"]}`, `"path":{"combine":"AND","matchers":[{"match":"regex","regex":"/users/\\d+"}]}`, `"header":{"Accept":`, `"body":{"$.name":`, `"generators":{"body":{"$.id":{"type":"RandomInt","min":1,"max":10}}}`, `"version":"3.0.0"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+}
+
+func TestPactFile_RoundTrip(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		data, err := json.Marshal(samplePactFile(version))
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+
+		var pact PactFile
+		if err = json.Unmarshal(data, &pact); err != nil {
+			t.Fatal("Error:", err)
+		}
+
+		if pact.SpecificationVersion() != version {
+			t.Fatalf("v%d: expected version %d, got %d", version, version, pact.SpecificationVersion())
+		}
+
+		i := pact.Interactions[0]
+		if i.ProviderStates[0].Name != "User billy exists" || i.Request.Query.Get("sort") != "asc" || i.Request.Headers["Accept"] != "application/json" {
+			t.Fatalf("v%d: unexpected interaction %+v", version, i)
+		}
+
+		for _, path := range []string{"$.path", "$.headers.Accept"} {
+			if len(i.Request.MatchingRules[path]) != 1 {
+				t.Fatalf("v%d: expected a request rule for %s, got %v", version, path, i.Request.MatchingRules)
+			}
+		}
+
+		if len(i.Response.MatchingRules["$.body.name"]) != 1 {
+			t.Fatalf("v%d: expected a response rule for $.body.name, got %v", version, i.Response.MatchingRules)
+		}
+
+		if version == 3 && i.Response.Generators["$.body.id"].Type != "RandomInt" {
+			t.Fatalf("v3: expected a generator for $.body.id, got %v", i.Response.Generators)
+		}
+	}
+}
+
+func TestPactFile_UnmarshalLegacy(t *testing.T) {
+	data := `{
+		"consumer": {"name": "billy"},
+		"provider": {"name": "bobby"},
+		"interactions": [{
+			"description": "A request for billy",
+			"provider_state": "User billy exists",
+			"request": {"method": "get", "path": "/users/1", "headers": {"Accept": ["text/plain", "application/json"]}},
+			"response": {"status": 200, "matchingRules": {"$.header.Content-Type": {"regex": "json"}, "$.body": {"min": 1}}}
+		}],
+		"metadata": {"pact-specification": {"version": "1.0.0"}}
+	}`
+
+	var pact PactFile
+	if err := json.Unmarshal([]byte(data), &pact); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if pact.Metadata.PactSpecification.Version != "1.0.0" {
+		t.Fatalf("expected the legacy specification version, got %v", pact.Metadata)
+	}
+
+	i := pact.Interactions[0]
+	if i.Request.Method != "GET" || i.ProviderStates[0].Name != "User billy exists" {
+		t.Fatalf("unexpected interaction %+v", i)
+	}
+
+	if i.Request.Headers["Accept"] != "text/plain, application/json" {
+		t.Fatalf("expected multi-valued headers to be joined, got %v", i.Request.Headers)
+	}
+
+	rules := i.Response.MatchingRules
+	if r := rules["$.headers.Content-Type"]; len(r) != 1 || r[0].Match != "regex" {
+		t.Fatalf("expected a normalised regex rule for the header, got %v", rules)
+	}
+
+	if r := rules["$.body"]; len(r) != 1 || r[0].Match != "type" || *r[0].Min != 1 {
+		t.Fatalf("expected an implicit type rule for the body, got %v", rules)
+	}
+}
+
+func TestPactFile_Messages(t *testing.T) {
+	pact := &PactFile{
+		Consumer: PactName{Name: "billy"},
+		Provider: PactName{Name: "bobby"},
+		Messages: []*PactMessage{
+			{
+				Description: "A user created event",
+				Contents:    map[string]interface{}{"name": "billy"},
+				Metadata:    map[string]interface{}{"queue": "users"},
+			},
+		},
+	}
+	pact.SetSpecificationVersion(3)
+
+	data, err := json.Marshal(pact)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if strings.Contains(string(data), `"interactions"`) || !strings.Contains(string(data), `"metaData":{"queue":"users"}`) {
+		t.Fatalf("unexpected message pact %s", data)
+	}
+
+	var parsed PactFile
+	if err = json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(parsed.Messages) != 1 || parsed.Messages[0].Metadata["queue"] != "users" {
+		t.Fatalf("unexpected messages %v", parsed.Messages)
+	}
+}
+
+func TestPactFile_splitCategory(t *testing.T) {
+	cases := map[string][2]string{
+		"$.body":                 {"body", "$"},
+		"$.body.items[*].name":   {"body", "$.items[*].name"},
+		"$.headers.Content-Type": {"header", "Content-Type"},
+		"$.query.sort":           {"query", "sort"},
+		"$.path":                 {"path", ""},
+	}
+
+	for path, want := range cases {
+		category, key := splitCategory(path)
+		if category != want[0] || key != want[1] {
+			t.Fatalf("splitCategory(%q): want %v, got %s %s", path, want, category, key)
+		}
+
+		if joined := joinCategory(category, key); joined != path {
+			t.Fatalf("joinCategory(%q, %q): want %s, got %s", category, key, path, joined)
+		}
+	}
+}
+
+func TestPactFile_MarshalV2DowngradesV3Rules(t *testing.T) {
+	pact := samplePactFile(2)
+	pact.Interactions[0].Response.MatchingRules = MatchingRules{
+		"$.body.id":    {{Match: "integer"}},
+		"$.body.name":  {{Match: "include", Value: "b.l"}},
+		"$.body.email": {{Match: "null"}},
+	}
+
+	data, err := json.Marshal(pact)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, s := range []string{`"$.body.id":{"match":"type"}`, `"$.body.name":{"match":"regex","regex":"b\\.l"}`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("expected pact file to contain %s, got %s", s, data)
+		}
+	}
+
+	if strings.Contains(string(data), "$.body.email") {
+		t.Fatalf("expected the null rule to be dropped, got %s", data)
+	}
+}
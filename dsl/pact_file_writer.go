@@ -0,0 +1,310 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// pactFileLockTimeout is how long to wait for another process to finish
+	// writing a Pact file.
+	pactFileLockTimeout = 60 * time.Second
+
+	// pactFileStaleLock is the age at which a lock is considered abandoned,
+	// e.g. by a test process that was killed mid-write.
+	pactFileStaleLock = 30 * time.Second
+)
+
+// pactFileName returns the conventional file name for a pact between a
+// consumer and provider e.g. "billy-bobby.json".
+func pactFileName(consumer string, provider string) string {
+	name := strings.ToLower(fmt.Sprintf("%s-%s.json", consumer, provider))
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// ReadPactFile reads a v1, v2 or v3 Pact file from disk.
+func ReadPactFile(file string) (*PactFile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var pact PactFile
+	if err = json.Unmarshal(data, &pact); err != nil {
+		return nil, fmt.Errorf("unable to parse pact file %s: %v", file, err)
+	}
+
+	return &pact, nil
+}
+
+// checkPactFileWriteMode returns an error if the write mode is not one that
+// WritePactFile supports.
+func checkPactFileWriteMode(writeMode string) error {
+	switch writeMode {
+	case "", "overwrite", "update", "merge", "none":
+		return nil
+	}
+
+	return fmt.Errorf("invalid pact file write mode '%s', must be one of overwrite, merge, update or none", writeMode)
+}
+
+// WritePactFile writes a Pact file into the given directory, named after
+// its consumer and provider. The write mode determines what happens to any
+// existing Pact file:
+//
+//	"overwrite" (default) replaces the existing file.
+//	"update" keeps existing interactions, replacing those with the same
+//	         description and provider state.
+//	"merge" keeps existing interactions, but fails if an interaction with the
+//	        same description and provider state differs.
+//	"none" does not write the file at all.
+//
+// Writes are guarded by a lock file, so that parallel test processes writing
+// into the same directory do not corrupt each other's output.
+func WritePactFile(pact *PactFile, dir string, writeMode string) error {
+	if pact.Consumer.Name == "" || pact.Provider.Name == "" {
+		return fmt.Errorf("Consumer and Provider name need to be provided")
+	}
+
+	if err := checkPactFileWriteMode(writeMode); err != nil {
+		return err
+	}
+	if writeMode == "none" {
+		log.Println("[DEBUG] pact file: write mode is 'none', skipping write")
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, pactFileName(pact.Consumer.Name, pact.Provider.Name))
+	for {
+		lock, err := lockFile(file)
+		if err != nil {
+			return err
+		}
+
+		written, err := writeLockedPactFile(lock, pact, file, writeMode)
+		lock.unlock()
+		if err != nil || written {
+			return err
+		}
+		log.Println("[WARN] pact file: lost the lock on", file, "to another writer, retrying")
+	}
+}
+
+// writeLockedPactFile writes the pact file while holding its lock. It
+// returns false, without writing, if the lock was lost in the meantime.
+func writeLockedPactFile(lock *pactFileLock, pact *PactFile, file string, writeMode string) (bool, error) {
+	if writeMode == "update" || writeMode == "merge" {
+		existing, err := ReadPactFile(file)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return false, err
+		default:
+			if pact, err = mergePactFiles(existing, pact, writeMode == "merge"); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pact); err != nil {
+		return false, err
+	}
+
+	log.Println("[DEBUG] pact file: writing pact file:", file)
+
+	// Write to a temporary file first, so that readers never see a partial file
+	dir := filepath.Dir(file)
+	tmp, err := ioutil.TempFile(dir, ".pact")
+	if err != nil {
+		return false, err
+	}
+	if _, err = tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	os.Chmod(tmp.Name(), 0644)
+
+	if !lock.held() {
+		os.Remove(tmp.Name())
+		return false, nil
+	}
+	if err = os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	return true, nil
+}
+
+// mergePactFiles adds the interactions and messages of the new pact into
+// the existing one. If strict, differing interactions with the same
+// description and provider state are an error, otherwise they are replaced.
+func mergePactFiles(existing *PactFile, pact *PactFile, strict bool) (*PactFile, error) {
+	merged := *existing
+	merged.Metadata = pact.Metadata
+	if existing.SpecificationVersion() > pact.SpecificationVersion() {
+		merged.Metadata = existing.Metadata
+	}
+	version := merged.SpecificationVersion()
+
+	merged.Interactions = append([]*PactInteraction{}, existing.Interactions...)
+	for _, interaction := range pact.Interactions {
+		found := false
+		for i, e := range merged.Interactions {
+			if e.key() != interaction.key() {
+				continue
+			}
+			found = true
+
+			if strict && !sameJSON(e.toJSON(version), interaction.toJSON(version)) {
+				return nil, fmt.Errorf("an interaction with the description '%s' and provider state '%s' already exists in the pact file but is different. Remove the pact file before running your tests, or use the 'update' write mode", interaction.Description, statesKey(interaction.ProviderStates))
+			}
+			merged.Interactions[i] = interaction
+		}
+
+		if !found {
+			merged.Interactions = append(merged.Interactions, interaction)
+		}
+	}
+
+	merged.Messages = append([]*PactMessage{}, existing.Messages...)
+	for _, message := range pact.Messages {
+		found := false
+		for i, e := range merged.Messages {
+			if e.key() != message.key() {
+				continue
+			}
+			found = true
+
+			if strict && !sameJSON(e.toJSON(version), message.toJSON(version)) {
+				return nil, fmt.Errorf("a message with the description '%s' and provider state '%s' already exists in the pact file but is different. Remove the pact file before running your tests, or use the 'update' write mode", message.Description, statesKey(message.ProviderStates))
+			}
+			merged.Messages[i] = message
+		}
+
+		if !found {
+			merged.Messages = append(merged.Messages, message)
+		}
+	}
+
+	return &merged, nil
+}
+
+// sameJSON compares two values by their JSON representation.
+func sameJSON(a interface{}, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+// pactFileLock is a lock on a Pact file. It is held for as long as the lock
+// file contains its token.
+type pactFileLock struct {
+	path  string
+	token string
+}
+
+// held reports whether the lock file still contains this lock's token.
+func (l *pactFileLock) held() bool {
+	data, err := ioutil.ReadFile(l.path)
+	return err == nil && string(data) == l.token
+}
+
+// unlock removes the lock file, unless another writer has since taken it.
+func (l *pactFileLock) unlock() {
+	if l.held() {
+		os.Remove(l.path)
+	}
+}
+
+// pactFileLockCount numbers the locks taken by this process, so that
+// goroutines writing the same file get different tokens.
+var pactFileLockCount uint64
+
+// breakStaleLock moves an abandoned lock out of the way, so that it can be
+// taken with O_EXCL. If the lock was replaced by a fresh one after it was
+// seen to be stale, it is put back, unless another writer has taken the lock
+// in the meantime. The owner of the fresh lock then finds that it no longer
+// holds it and writes again.
+func breakStaleLock(lock string) {
+	stale := fmt.Sprintf("%s.%d.%d.stale", lock, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lock, stale); err != nil {
+		return
+	}
+	defer os.Remove(stale)
+
+	if info, err := os.Stat(stale); err == nil && time.Since(info.ModTime()) <= pactFileStaleLock {
+		os.Link(stale, lock)
+		return
+	}
+	log.Println("[WARN] pact file: removed stale lock file:", lock)
+}
+
+// lockFile acquires an exclusive lock on a file, shared across processes, by
+// atomically creating a ".lock" file alongside it that contains a token
+// unique to this lock.
+//
+// Writers should check the lock is still held before writing, as a lock can
+// be lost to another writer breaking a lock it wrongly took to be stale.
+// The check narrows, but does not close, the window in which two writers
+// can both write.
+func lockFile(file string) (*pactFileLock, error) {
+	lock := file + ".lock"
+	timeout := time.After(pactFileLockTimeout)
+
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			l := &pactFileLock{
+				path:  lock,
+				token: fmt.Sprintf("%d-%d", os.Getpid(), atomic.AddUint64(&pactFileLockCount, 1)),
+			}
+			_, err = fmt.Fprint(f, l.token)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lock)
+				return nil, err
+			}
+			return l, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(lock); statErr == nil && time.Since(info.ModTime()) > pactFileStaleLock {
+			breakStaleLock(lock)
+			continue
+		}
+
+		select {
+		case <-timeout:
+			return nil, fmt.Errorf("timed out after %s waiting for the lock on %s", pactFileLockTimeout, file)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package dsl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writePactInteraction(t *testing.T, dir string, mode string, description string, status int) error {
+	pact := &PactFile{
+		Consumer: PactName{Name: "billy"},
+		Provider: PactName{Name: "bobby"},
+		Interactions: []*PactInteraction{
+			{
+				Description: description,
+				Request:     PactRequest{Method: "GET", Path: "/"},
+				Response:    PactResponse{Status: status},
+			},
+		},
+	}
+
+	return WritePactFile(pact, dir, mode)
+}
+
+func readPactInteractions(t *testing.T, dir string) []*PactInteraction {
+	pact, err := ReadPactFile(filepath.Join(dir, "billy-bobby.json"))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return pact.Interactions
+}
+
+func TestWritePactFile_Overwrite(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	writePactInteraction(t, dir, "", "first", 200)
+	if err := writePactInteraction(t, dir, "overwrite", "second", 200); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if i := readPactInteractions(t, dir); len(i) != 1 || i[0].Description != "second" {
+		t.Fatalf("expected only the second interaction, got %v", i)
+	}
+}
+
+func TestWritePactFile_Update(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	writePactInteraction(t, dir, "update", "first", 200)
+	writePactInteraction(t, dir, "update", "second", 200)
+	if err := writePactInteraction(t, dir, "update", "first", 404); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	i := readPactInteractions(t, dir)
+	if len(i) != 2 || i[0].Description != "first" || i[0].Response.Status != 404 {
+		t.Fatalf("expected the first interaction to be updated, got %v", i)
+	}
+}
+
+func TestWritePactFile_Merge(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	writePactInteraction(t, dir, "merge", "first", 200)
+	writePactInteraction(t, dir, "merge", "second", 200)
+	if err := writePactInteraction(t, dir, "merge", "first", 200); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if i := readPactInteractions(t, dir); len(i) != 2 {
+		t.Fatalf("expected two interactions, got %v", i)
+	}
+
+	if err := writePactInteraction(t, dir, "merge", "first", 404); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestWritePactFile_None(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	if err := writePactInteraction(t, dir, "none", "first", 200); err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "billy-bobby.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no pact file to be written, got %v", err)
+	}
+}
+
+func TestWritePactFile_Fail(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	if err := WritePactFile(&PactFile{}, dir, ""); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if err := writePactInteraction(t, dir, "append", "first", 200); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestWritePactFile_ConcurrentMerge(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- writePactInteraction(t, dir, "merge", fmt.Sprintf("interaction %d", i), 200)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+
+	if i := readPactInteractions(t, dir); len(i) != 10 {
+		t.Fatalf("expected 10 interactions, got %d", len(i))
+	}
+}
+
+func TestWritePactFile_LockTimeout(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	defer func(timeout time.Duration) { pactFileLockTimeout = timeout }(pactFileLockTimeout)
+	pactFileLockTimeout = 50 * time.Millisecond
+
+	ioutil.WriteFile(filepath.Join(dir, "billy-bobby.json.lock"), []byte("1"), 0644)
+	if err := writePactInteraction(t, dir, "", "first", 200); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
+
+func TestWritePactFile_StaleLock(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	lock := filepath.Join(dir, "billy-bobby.json.lock")
+	ioutil.WriteFile(lock, []byte("1"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(lock, old, old)
+
+	if err := writePactInteraction(t, dir, "", "first", 200); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func Test_lockFileStaleLockTakenOnce(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "billy-bobby.json")
+	ioutil.WriteFile(file+".lock", []byte("1"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(file+".lock", old, old)
+
+	var mu sync.Mutex
+	holders, maxHolders := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := lockFile(file)
+			if err != nil {
+				t.Error("Error:", err)
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > maxHolders {
+				maxHolders = holders
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			lock.unlock()
+		}()
+	}
+	wg.Wait()
+
+	if maxHolders != 1 {
+		t.Fatalf("expected the lock to be held by one writer at a time, got %d", maxHolders)
+	}
+}
+
+func Test_lockFileLost(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "billy-bobby.json")
+	lock, err := lockFile(file)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !lock.held() {
+		t.Fatalf("expected the lock to be held")
+	}
+
+	// Another writer takes over the lock
+	ioutil.WriteFile(file+".lock", []byte("1-1"), 0644)
+	if lock.held() {
+		t.Fatalf("expected the lock to be lost")
+	}
+
+	lock.unlock()
+	if data, _ := ioutil.ReadFile(file + ".lock"); string(data) != "1-1" {
+		t.Fatalf("expected the other writer's lock to be left in place, got %q", data)
+	}
+}
@@ -0,0 +1,254 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// newPactInteraction converts a DSL interaction, as serialised to the Mock
+// Service, into its pact file form.
+func newPactInteraction(data []byte) (*PactInteraction, error) {
+	var raw struct {
+		Description    string  `json:"description"`
+		ProviderState  string  `json:"providerState"`
+		ProviderStates []State `json:"providerStates"`
+		Request        struct {
+			Method  string                 `json:"method"`
+			Path    interface{}            `json:"path"`
+			Query   map[string]interface{} `json:"query"`
+			Headers map[string]interface{} `json:"headers"`
+			Body    interface{}            `json:"body"`
+		} `json:"request"`
+		Response struct {
+			Status  int                    `json:"status"`
+			Headers map[string]interface{} `json:"headers"`
+			Body    interface{}            `json:"body"`
+		} `json:"response"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse interaction: %v", err)
+	}
+
+	if raw.Description == "" {
+		return nil, fmt.Errorf("interaction is missing a description")
+	}
+
+	i := &PactInteraction{
+		Description:    raw.Description,
+		ProviderStates: unmarshalStates(raw.ProviderStates, raw.ProviderState, ""),
+		Request: PactRequest{
+			Method:        strings.ToUpper(raw.Request.Method),
+			MatchingRules: make(MatchingRules),
+		},
+		Response: PactResponse{
+			Status:        raw.Response.Status,
+			MatchingRules: make(MatchingRules),
+		},
+	}
+
+	if i.Request.Method == "" {
+		i.Request.Method = "GET"
+	}
+
+	if i.Response.Status == 0 {
+		i.Response.Status = http.StatusOK
+	}
+
+	i.Request.Path = fmt.Sprint(extractRules(raw.Request.Path, []string{"$", "path"}, i.Request.MatchingRules))
+	if i.Request.Path == "<nil>" {
+		i.Request.Path = "/"
+	}
+
+	if len(raw.Request.Query) > 0 {
+		i.Request.Query = make(url.Values)
+		for key, value := range raw.Request.Query {
+			example := extractRules(value, []string{"$", "query", key}, i.Request.MatchingRules)
+			switch e := example.(type) {
+			case []interface{}:
+				for _, v := range e {
+					i.Request.Query.Add(key, fmt.Sprint(v))
+				}
+			default:
+				i.Request.Query.Add(key, fmt.Sprint(e))
+			}
+		}
+	}
+
+	i.Request.Headers = extractHeaders(raw.Request.Headers, i.Request.MatchingRules)
+	i.Response.Headers = extractHeaders(raw.Response.Headers, i.Response.MatchingRules)
+	i.Request.Body = extractRules(raw.Request.Body, []string{"$", "body"}, i.Request.MatchingRules)
+	i.Response.Body = extractRules(raw.Response.Body, []string{"$", "body"}, i.Response.MatchingRules)
+
+	return i, nil
+}
+
+func extractHeaders(headers map[string]interface{}, rules MatchingRules) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(headers))
+	for key, value := range headers {
+		result[key] = fmt.Sprint(extractRules(value, []string{"$", "headers", key}, rules))
+	}
+
+	return result
+}
+
+// String describes the request of an interaction e.g. "GET /users".
+func (r PactRequest) String() string {
+	if len(r.Query) == 0 {
+		return fmt.Sprintf("%s %s", r.Method, r.Path)
+	}
+	return fmt.Sprintf("%s %s?%s", r.Method, r.Path, r.Query.Encode())
+}
+
+// Mismatches compares an HTTP request, with the body already read from it,
+// to the expected request. The request matches if there are no mismatches.
+func (r PactRequest) Mismatches(req *http.Request, body []byte) []Mismatch {
+	return r.match(req.Method, req.URL.Path, req.URL.Query(), req.Header, body)
+}
+
+// match compares an actual request to the expected request, returning any
+// differences found.
+func (r PactRequest) match(method string, path string, query url.Values, headers http.Header, body []byte) []Mismatch {
+	c := &comparison{rules: r.MatchingRules}
+
+	if !strings.EqualFold(r.Method, method) {
+		c.mismatch("method", []string{"$", "method"}, r.Method, method, "Expected method %s but received %s", r.Method, method)
+	}
+
+	c.compare("path", []string{"$", "path"}, r.Path, path)
+	c.compareQuery(r.Query, query)
+	c.compareHeaders(r.Headers, headers)
+	c.compareBody(r.Body, headers.Get("Content-Type"), body)
+
+	return c.mismatches
+}
+
+// match compares an actual response to the expected response, returning any
+// differences found.
+func (r PactResponse) match(status int, headers http.Header, body []byte) []Mismatch {
+	c := &comparison{rules: r.MatchingRules, allowUnexpectedKeys: true}
+
+	if r.Status != status {
+		c.mismatch("status", []string{"$", "status"}, r.Status, status, "Expected status %d but received %d", r.Status, status)
+	}
+
+	c.compareHeaders(r.Headers, headers)
+	c.compareBody(r.Body, headers.Get("Content-Type"), body)
+
+	return c.mismatches
+}
+
+func (c *comparison) compareQuery(expected url.Values, actual url.Values) {
+	for _, key := range sortedValueKeys(expected) {
+		path := []string{"$", "query", key}
+		values, ok := actual[key]
+		if !ok {
+			c.mismatch("query", path, expected[key], nil, "Expected query parameter '%s' but was missing", key)
+			continue
+		}
+
+		if len(values) != len(expected[key]) && len(c.rules.resolve(path)) == 0 {
+			c.mismatch("query", path, expected[key], values, "Expected query parameter '%s' with %d value(s) but received %d", key, len(expected[key]), len(values))
+			continue
+		}
+
+		for i, value := range values {
+			c.compare("query", appendPath(path, strconv.Itoa(i)), expected[key][i%len(expected[key])], value)
+		}
+	}
+
+	for _, key := range sortedValueKeys(actual) {
+		if _, ok := expected[key]; !ok {
+			c.mismatch("query", []string{"$", "query", key}, nil, actual[key], "Unexpected query parameter '%s' received", key)
+		}
+	}
+}
+
+var headerValueSeparator = regexp.MustCompile(`\s*([,;])\s*`)
+
+func (c *comparison) compareHeaders(expected map[string]string, actual http.Header) {
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := []string{"$", "headers", key}
+		values, ok := actual[http.CanonicalHeaderKey(key)]
+		if !ok {
+			c.mismatch("header", path, expected[key], nil, "Expected header '%s' but was missing", key)
+			continue
+		}
+
+		value := strings.Join(values, ", ")
+		if len(c.rules.resolve(path)) > 0 {
+			c.compare("header", path, expected[key], value)
+			continue
+		}
+
+		e := headerValueSeparator.ReplaceAllString(strings.TrimSpace(expected[key]), "$1")
+		a := headerValueSeparator.ReplaceAllString(strings.TrimSpace(value), "$1")
+		if e != a {
+			c.mismatch("header", path, expected[key], value, "Expected header '%s' to have value '%s' but was '%s'", key, expected[key], value)
+		}
+	}
+}
+
+func (c *comparison) compareBody(expected interface{}, contentType string, body []byte) {
+	if expected == nil {
+		return
+	}
+
+	path := []string{"$", "body"}
+	if len(body) == 0 {
+		c.mismatch("body", path, expected, nil, "Expected a body but none was received")
+		return
+	}
+
+	if e, ok := expected.(string); ok {
+		var parsed interface{}
+		if !isJSONContentType(contentType) || json.Unmarshal([]byte(e), &parsed) != nil {
+			c.compare("body", path, e, string(body))
+			return
+		}
+		expected = parsed
+	}
+
+	// Decode numbers as json.Number, so that integers and decimals can be
+	// told apart by the matchers
+	var actual interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&actual); err != nil {
+		c.mismatch("body", path, expected, string(body), "Expected a JSON body but received '%s': %v", body, err)
+		return
+	}
+
+	c.compare("body", path, expected, actual)
+}
+
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || strings.Contains(strings.ToLower(contentType), "json")
+}
+
+func sortedValueKeys(v url.Values) []string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,9 +1,11 @@
 package dsl
 
 import (
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -332,6 +334,78 @@ func TestPact_VerifyProviderRawFail(t *testing.T) {
 	}
 }
 
+func TestPact_VerifyMessageConsumerRaw(t *testing.T) {
+	c, svc := createClient(true)
+	pact := &Pact{LogLevel: "DEBUG", pactClient: c, Consumer: "billy", Provider: "bobby"}
+
+	message := pact.AddMessage()
+	message.
+		ExpectsToReceive("a user").
+		WithContent(map[string]interface{}{
+			"id":    Integer(),
+			"price": Decimal(),
+		})
+
+	err := pact.VerifyMessageConsumerRaw(message, func(m Message) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(svc.Args) < 2 || svc.Args[0] != "update" {
+		t.Fatalf("expected the message pact to be updated, got %v", svc.Args)
+	}
+	if strings.Contains(svc.Args[1], "pact:matcher:type") || !strings.Contains(svc.Args[1], `"id":{"contents":42,"json_class":"Pact::SomethingLike"}`) {
+		t.Fatalf("expected version 3 matchers to be converted for the Ruby tools, got %s", svc.Args[1])
+	}
+	if _, ok := message.Content.(map[string]interface{})["id"].(Matcher); !ok {
+		t.Fatalf("expected the message content to be left unchanged, got %v", message.Content)
+	}
+}
+
+func TestPact_verifyPending(t *testing.T) {
+	var verified [][]string
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		verified = append(verified, request.PactURLs)
+		res := types.ProviderVerifierResponse{}
+		for _, pactURL := range request.PactURLs {
+			res.Examples = append(res.Examples, types.ProviderVerifierExample{Description: pactURL, Status: "failed"})
+		}
+		res.Summary.ExampleCount = len(res.Examples)
+		res.Summary.FailureCount = len(res.Examples)
+		return res, fmt.Errorf("error verifying provider")
+	}
+
+	res, err := verifyPending(types.VerifyRequest{
+		PactURLs: []string{"foo.json", "bar.json"},
+	}, map[string]bool{"bar.json": true}, verify)
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if !reflect.DeepEqual(verified, [][]string{{"foo.json"}, {"bar.json"}}) {
+		t.Fatalf("Expected pending pacts to be verified separately but got %v", verified)
+	}
+	if len(res.Examples) != 2 || res.Examples[0].Pending || !res.Examples[1].Pending {
+		t.Fatalf("Expected the pending example to be flagged but got %+v", res.Examples)
+	}
+	if res.Summary.ExampleCount != 2 || res.Summary.FailureCount != 1 || res.Summary.PendingCount != 1 {
+		t.Fatalf("Expected 2 examples with 1 failure and 1 pending but got %+v", res.Summary)
+	}
+
+	// Only pending pacts do not fail the verification
+	res, err = verifyPending(types.VerifyRequest{
+		PactURLs: []string{"bar.json"},
+	}, map[string]bool{"bar.json": true}, verify)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(res.Examples) != 1 || !res.Examples[0].Pending {
+		t.Fatalf("Expected a pending example but got %+v", res.Examples)
+	}
+}
+
 func TestPact_AddInteraction(t *testing.T) {
 	pact := &Pact{}
 	defer stubPorts()()
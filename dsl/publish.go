@@ -1,7 +1,7 @@
 package dsl
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,30 +9,19 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/pact-foundation/pact-go/broker"
 	"github.com/pact-foundation/pact-go/types"
 )
 
-// PactFile is a simple representation of a Pact file to be able to
-// parse Consumer/Provider from the file.
-type PactFile struct {
-	// The API Consumer name
-	Consumer PactName `json:"consumer"`
-
-	// The API Provider name
-	Provider PactName `json:"provider"`
-}
-
-// PactName represents the name fields in the PactFile.
-type PactName struct {
-	Name string `json:"name"`
-}
-
 // Publisher is the API to send Pact files to a Pact Broker.
 type Publisher struct {
 	request types.PublishRequest
 	client  *http.Client
+	ctx     context.Context
 }
 
 // validate the publish requests.
@@ -45,14 +34,8 @@ func (p *Publisher) validate() error {
 	}
 
 	// Validate that the files exist on the system
-	var err error
-	for _, url := range p.request.PactURLs {
-		// Only check local files
-		if !strings.HasPrefix(url, "http") {
-			if _, err = os.Stat(url); err != nil {
-				return err
-			}
-		}
+	if _, err := expandPactURLs(p.request.PactURLs); err != nil {
+		return err
 	}
 
 	if p.request.PactBroker == "" {
@@ -67,39 +50,39 @@ func (p *Publisher) validate() error {
 		return errors.New("Must provide both or none of BrokerUsername and BrokerPassword")
 	}
 
+	if p.request.BrokerToken != "" && p.request.BrokerUsername != "" {
+		return errors.New("BrokerToken cannot be used with BrokerUsername and BrokerPassword")
+	}
+
 	return nil
 }
 
-// call sends a message to the Pact Broker.
-func (p *Publisher) call(method string, url string, content []byte) error {
-	if p.client == nil {
-		p.client = &http.Client{}
-	}
-	var req *http.Request
-	var err error
-	req, err = http.NewRequest(method, url, bytes.NewReader(content))
+// brokerClient creates a client for the Pact Broker of a publish request.
+// The client set with SetClient takes precedence over the BrokerTransport.
+func (p *Publisher) brokerClient(request types.PublishRequest) (*broker.Client, error) {
+	client, err := brokerClient(request.PactBroker, request.BrokerUsername, request.BrokerPassword, request.BrokerToken, request.BrokerTransport)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	if p.client != nil {
+		client.HTTPClient = p.client
+	}
+	client.DryRun = request.DryRun
 
-	req.Header.Set("Content-Type", "application/json")
-
-	if p.request.BrokerUsername != "" && p.request.BrokerPassword != "" {
-		req.SetBasicAuth(p.request.BrokerUsername, p.request.BrokerPassword)
+	if p.ctx != nil {
+		return client.WithContext(p.ctx), nil
 	}
+	return client, nil
+}
 
-	res, err := p.client.Do(req)
+// call sends a message to the Pact Broker.
+func (p *Publisher) call(method string, url string, content []byte) error {
+	client, err := p.brokerClient(p.request)
 	if err != nil {
 		return err
 	}
 
-	responseBody, err := ioutil.ReadAll(res.Body)
-	res.Body.Close()
-	log.Printf("[DEBUG] pact publisher response Body: %s\n", responseBody)
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return errors.New(string(responseBody))
-	}
-	return err
+	return client.Send(method, url, content, nil)
 }
 
 // readPactFile reads Pact files from local or remote sources.
@@ -139,15 +122,15 @@ func (p *Publisher) unmarshal(content []byte) (*PactFile, error) {
 	return &pactFile, err
 }
 
-// readRemotePactFile reads a remote Pact file from an http(s) server.
+// readRemotePactFile reads a remote Pact file from an http(s) server, with
+// the credentials and transport of the Pact Broker.
 func (p *Publisher) readRemotePactFile(file string) (*PactFile, []byte, error) {
 	log.Println("[DEBUG] pact publisher: read remote pact file", file)
-	res, err := http.Get(file)
+	client, err := p.brokerClient(p.request)
 	if err != nil {
 		return nil, nil, err
 	}
-	data, err := ioutil.ReadAll(res.Body)
-	res.Body.Close()
+	_, data, err := client.Pact(file)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -156,26 +139,115 @@ func (p *Publisher) readRemotePactFile(file string) (*PactFile, []byte, error) {
 	return f, data, err
 }
 
-// Publish sends the Pacts to a broker, optionally tagging them
+// Publish sends the Pacts to a broker, optionally tagging them. Every Pact is
+// published even if some of them fail, and the error lists those that did.
 func (p *Publisher) Publish(request types.PublishRequest) error {
+	return p.PublishContext(context.Background(), request)
+}
+
+// PublishContext is Publish with a context, which cancels the requests to
+// the broker.
+func (p *Publisher) PublishContext(ctx context.Context, request types.PublishRequest) error {
+	_, err := p.PublishWithResultContext(ctx, request)
+	return err
+}
+
+// PublishWithResult sends the Pacts to a broker as Publish does, returning
+// the result of publishing each Pact file.
+func (p *Publisher) PublishWithResult(request types.PublishRequest) (types.PublishResult, error) {
+	return p.PublishWithResultContext(context.Background(), request)
+}
+
+// PublishWithResultContext is PublishWithResult with a context, which
+// cancels the requests to the broker.
+func (p *Publisher) PublishWithResultContext(ctx context.Context, request types.PublishRequest) (types.PublishResult, error) {
 	log.Println("[DEBUG] pact publisher: publish pact")
+	result := types.PublishResult{
+		DryRun: request.DryRun,
+	}
 	p.request = request
+	p.ctx = ctx
+	if err := p.validate(); err != nil {
+		return result, err
+	}
+	client, err := p.brokerClient(request)
+	if err != nil {
+		return result, err
+	}
 
-	for _, url := range request.PactURLs {
-		file, data, err := p.readPactFile(url)
-		if err != nil {
-			return err
+	pactURLs, err := expandPactURLs(request.PactURLs)
+	if err != nil {
+		return result, err
+	}
+
+	if !request.DisableGitBranchTag {
+		if branch, err := gitBranch(); err != nil {
+			log.Println("[WARN] pact publisher: not tagging the pacts with the git branch:", err)
+		} else if !hasTag(request.Tags, branch) {
+			request.Tags = append(append([]string{}, request.Tags...), branch)
+		}
+	}
+
+	versions := make(map[string]bool)
+	var errs []string
+	for _, url := range pactURLs {
+		pact := types.PactPublishResult{File: url}
+		if err = p.publishPact(client, url, request, &pact, versions); err != nil {
+			log.Printf("[ERROR] pact publisher: unable to publish %s: %v", url, err)
+			pact.Error = err.Error()
+			errs = append(errs, fmt.Sprintf("%s: %v", url, err))
 		}
+		result.Pacts = append(result.Pacts, pact)
+	}
+
+	switch {
+	case len(errs) == 0:
+		return result, nil
+	case len(pactURLs) == 1:
+		// A single Pact's error needs no summary
+		return result, err
+	}
+
+	return result, fmt.Errorf("unable to publish %d of %d pacts:\n%s", len(errs), len(pactURLs), strings.Join(errs, "\n"))
+}
 
-		endpoint := fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/version/%s", request.PactBroker, file.Provider.Name, file.Consumer.Name, request.ConsumerVersion)
-		log.Println("[DEBUG] pact publisher: putting Pact on endpoint:", endpoint)
-		err = p.call("PUT", endpoint, data)
+// publishPact sends a single Pact file to the broker, recording its details
+// in the result. The branch and build URL of each consumer version are only
+// set once.
+func (p *Publisher) publishPact(client *broker.Client, url string, request types.PublishRequest, result *types.PactPublishResult, versions map[string]bool) error {
+	file, data, err := p.readPactFile(url)
+	if err != nil {
+		return err
+	}
+	result.Consumer = file.Consumer.Name
+	result.Provider = file.Provider.Name
+
+	if (request.Branch != "" || request.BuildURL != "") && !versions[file.Consumer.Name] {
+		log.Println("[DEBUG] pact publisher: setting branch", request.Branch, "of", file.Consumer.Name, "version", request.ConsumerVersion)
+		err = client.UpdateVersion(file.Consumer.Name, request.ConsumerVersion, broker.VersionProperties{
+			Branch:   request.Branch,
+			BuildURL: request.BuildURL,
+		})
 		if err != nil {
-			return err
+			return fmt.Errorf("unable to update version %s of %s: %v", request.ConsumerVersion, file.Consumer.Name, err)
 		}
+		versions[file.Consumer.Name] = true
+	}
 
-		p.tagRequest(file.Consumer.Name, request)
+	log.Println("[DEBUG] pact publisher: publishing Pact between", file.Consumer.Name, "and", file.Provider.Name)
+	if result.URL, err = client.PactURL(file.Provider.Name, file.Consumer.Name, request.ConsumerVersion); err != nil {
+		return err
+	}
+	result.Digest = broker.Digest(data)
+	err = client.PublishPact(file.Provider.Name, file.Consumer.Name, request.ConsumerVersion, data)
+	if err != nil {
+		return err
+	}
+
+	if err = p.tagRequest(file.Consumer.Name, request); err != nil {
+		return err
 	}
+	result.Tags = request.Tags
 
 	return nil
 }
@@ -183,12 +255,15 @@ func (p *Publisher) Publish(request types.PublishRequest) error {
 // tag one or more Pact files
 func (p *Publisher) tagRequest(consumerName string, request types.PublishRequest) error {
 	log.Println("[DEBUG] pact publisher: tagging pacts...")
+	client, err := p.brokerClient(request)
+	if err != nil {
+		return err
+	}
 	for _, tag := range request.Tags {
-		endpoint := fmt.Sprintf("%s/pacticipants/%s/versions/%s/tags/%s", request.PactBroker, consumerName, request.ConsumerVersion, tag)
-		log.Println("[DEBUG] pact publisher: tagging Pact:", endpoint)
-		err := p.call("PUT", endpoint, []byte{})
+		log.Println("[DEBUG] pact publisher: tagging Pact with:", tag)
+		err := client.TagVersion(consumerName, request.ConsumerVersion, tag)
 		if err != nil {
-			return err
+			return fmt.Errorf("unable to tag version %s of %s with '%s': %v", request.ConsumerVersion, consumerName, tag, err)
 		}
 	}
 
@@ -199,3 +274,84 @@ func (p *Publisher) tagRequest(consumerName string, request types.PublishRequest
 func (p *Publisher) SetClient(client *http.Client) {
 	p.client = client
 }
+
+// expandPactURLs expands the directories and glob patterns of a publish
+// request to the Pact files they contain. URLs are left as they are. All the
+// paths that are invalid are reported together.
+func expandPactURLs(pactURLs []string) ([]string, error) {
+	var files []string
+	var errs []string
+	for _, pactURL := range pactURLs {
+		if isURL(pactURL) {
+			files = append(files, pactURL)
+			continue
+		}
+
+		matches := []string{pactURL}
+		if strings.ContainsAny(pactURL, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(pactURL); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid pattern %s: %v", pactURL, err))
+				continue
+			}
+			if len(matches) == 0 {
+				errs = append(errs, fmt.Sprintf("no pact files match %s", pactURL))
+				continue
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if !info.IsDir() {
+				files = append(files, match)
+				continue
+			}
+
+			dirFiles, _ := filepath.Glob(filepath.Join(match, "*.json"))
+			if len(dirFiles) == 0 {
+				errs = append(errs, fmt.Sprintf("no pact files found in %s", match))
+				continue
+			}
+			files = append(files, dirFiles...)
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return files, nil
+	case 1:
+		return nil, errors.New(errs[0])
+	}
+
+	return nil, fmt.Errorf("%d invalid pact files:\n%s", len(errs), strings.Join(errs, "\n"))
+}
+
+// hasTag is true if the tag is one of the tags.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gitBranch returns the current git branch.
+var gitBranch = func() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the current git branch: %v", err)
+	}
+
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return "", errors.New("unable to find the current git branch: HEAD is detached")
+	}
+
+	return branch, nil
+}
@@ -9,9 +9,11 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/pact-foundation/pact-go/broker"
 	"github.com/pact-foundation/pact-go/types"
 	"github.com/pact-foundation/pact-go/utils"
 )
@@ -69,6 +71,10 @@ var checkAuth = func(w http.ResponseWriter, r *http.Request) bool {
 		return false
 	}
 
+	if s[0] == "Bearer" {
+		return s[1] == "abcd"
+	}
+
 	b, err := base64.StdEncoding.DecodeString(s[1])
 	if err != nil {
 		return false
@@ -456,6 +462,25 @@ func TestPublish_PublishWithAuth(t *testing.T) {
 	}
 }
 
+func TestPublish_PublishWithToken(t *testing.T) {
+	p := &Publisher{}
+
+	f := createSimplePact(true)
+	broker := createMockRemoteServerWithAuth(true)
+	defer broker.Close()
+
+	err := p.Publish(types.PublishRequest{
+		PactURLs:        []string{f.Name()},
+		PactBroker:      broker.URL,
+		ConsumerVersion: "1.0.0",
+		BrokerToken:     "abcd",
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+}
+
 func TestPublish_PublishWithAuthFail(t *testing.T) {
 	p := &Publisher{}
 
@@ -522,3 +547,199 @@ func TestPublish_SetClient(t *testing.T) {
 		t.Fatalf("SetClient Failed To Set Client On Publisher")
 	}
 }
+
+// writePactDir writes a Pact file for each consumer into a new directory.
+func writePactDir(t *testing.T, consumers ...string) string {
+	dir, err := ioutil.TempDir("", "pactgo")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, consumer := range consumers {
+		data := fmt.Sprintf(`{"consumer":{"name":"%s"},"provider":{"name":"Some Provider"}}`, consumer)
+		if err = ioutil.WriteFile(filepath.Join(dir, consumer+".json"), []byte(data), 0644); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+
+	return dir
+}
+
+func TestPublish_expandPactURLs(t *testing.T) {
+	dir := writePactDir(t, "billy", "jessica")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a pact"), 0644)
+
+	billy := filepath.Join(dir, "billy.json")
+	jessica := filepath.Join(dir, "jessica.json")
+	tests := []struct {
+		pactURLs []string
+		expected []string
+	}{
+		{[]string{billy}, []string{billy}},
+		{[]string{dir}, []string{billy, jessica}},
+		{[]string{filepath.Join(dir, "j*.json")}, []string{jessica}},
+		{[]string{"http://broker/pacts/1", dir}, []string{"http://broker/pacts/1", billy, jessica}},
+	}
+
+	for _, test := range tests {
+		files, err := expandPactURLs(test.pactURLs)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if !reflect.DeepEqual(files, test.expected) {
+			t.Fatalf("expected %v to expand to %v, got %v", test.pactURLs, test.expected, files)
+		}
+	}
+
+	empty, _ := ioutil.TempDir("", "pactgo")
+	defer os.RemoveAll(empty)
+	for _, pactURLs := range [][]string{{empty}, {filepath.Join(dir, "*.pact")}, {filepath.Join(dir, "missing.json")}, {"["}} {
+		if _, err := expandPactURLs(pactURLs); err == nil {
+			t.Fatalf("Expected error but got none for %v", pactURLs)
+		}
+	}
+
+	// Every invalid path is reported
+	_, err := expandPactURLs([]string{empty, billy, filepath.Join(dir, "missing.json")})
+	if err == nil || !strings.Contains(err.Error(), "2 invalid pact files") || !strings.Contains(err.Error(), empty) || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("expected both invalid paths to be reported, got %v", err)
+	}
+}
+
+func TestPublish_PublishWithResult(t *testing.T) {
+	dir := writePactDir(t, "billy", "jessica")
+	defer os.RemoveAll(dir)
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != http.MethodGet {
+			requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.EscapedPath(), body))
+		}
+		if strings.Contains(r.URL.Path, "consumer/jessica") {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	defer func(branch func() (string, error)) { gitBranch = branch }(gitBranch)
+	gitBranch = func() (string, error) {
+		return "feat/foo", nil
+	}
+
+	p := &Publisher{}
+	result, err := p.PublishWithResult(types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      server.URL,
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"dev"},
+		Branch:          "feat/foo",
+		BuildURL:        "http://ci/1",
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "unable to publish 1 of 2 pacts") {
+		t.Fatalf("expected the failed pact to be reported, got %v", err)
+	}
+
+	expected := []types.PactPublishResult{
+		{
+			File:     filepath.Join(dir, "billy.json"),
+			Consumer: "billy",
+			Provider: "Some Provider",
+			URL:      server.URL + "/pacts/provider/Some%20Provider/consumer/billy/version/1.0.0",
+			Digest:   broker.Digest([]byte(`{"consumer":{"name":"billy"},"provider":{"name":"Some Provider"}}`)),
+			Tags:     []string{"dev", "feat/foo"},
+		},
+		{
+			File:     filepath.Join(dir, "jessica.json"),
+			Consumer: "jessica",
+			Provider: "Some Provider",
+			URL:      server.URL + "/pacts/provider/Some%20Provider/consumer/jessica/version/1.0.0",
+			Digest:   broker.Digest([]byte(`{"consumer":{"name":"jessica"},"provider":{"name":"Some Provider"}}`)),
+			Error:    "something went wrong\n",
+		},
+	}
+	if !reflect.DeepEqual(result.Pacts, expected) {
+		t.Fatalf("expected results %+v, got %+v", expected, result.Pacts)
+	}
+
+	expectedRequests := []string{
+		`PUT /pacticipants/billy/versions/1.0.0 {"branch":"feat/foo","buildUrl":"http://ci/1"}`,
+		`PUT /pacts/provider/Some%20Provider/consumer/billy/version/1.0.0 {"consumer":{"name":"billy"},"provider":{"name":"Some Provider"}}`,
+		"PUT /pacticipants/billy/versions/1.0.0/tags/dev ",
+		"PUT /pacticipants/billy/versions/1.0.0/tags/feat%2Ffoo ",
+		`PUT /pacticipants/jessica/versions/1.0.0 {"branch":"feat/foo","buildUrl":"http://ci/1"}`,
+		`PUT /pacts/provider/Some%20Provider/consumer/jessica/version/1.0.0 {"consumer":{"name":"jessica"},"provider":{"name":"Some Provider"}}`,
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Fatalf("expected requests %v, got %v", expectedRequests, requests)
+	}
+}
+
+func TestPublish_PublishTagFail(t *testing.T) {
+	dir := writePactDir(t, "billy")
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/tags/") {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer server.Close()
+
+	p := &Publisher{}
+	result, err := p.PublishWithResult(types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      server.URL,
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"prod"},
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "unable to tag version 1.0.0 of billy with 'prod'") {
+		t.Fatalf("expected the tagging error, got %v", err)
+	}
+	if len(result.Pacts) != 1 || result.Pacts[0].Error == "" || len(result.Pacts[0].Tags) != 0 {
+		t.Fatalf("expected the pact to fail, got %+v", result)
+	}
+}
+
+func TestPublish_PublishDryRun(t *testing.T) {
+	dir := writePactDir(t, "billy")
+	defer os.RemoveAll(dir)
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	p := &Publisher{}
+	result, err := p.PublishWithResult(types.PublishRequest{
+		PactURLs:        []string{dir},
+		PactBroker:      server.URL,
+		ConsumerVersion: "1.0.0",
+		Tags:            []string{"prod"},
+		Branch:          "main",
+		DryRun:          true,
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	for _, method := range methods {
+		if method != http.MethodGet {
+			t.Fatalf("expected nothing to be sent in a dry run, got a %s", method)
+		}
+	}
+
+	if !result.DryRun || len(result.Pacts) != 1 || result.Pacts[0].Digest == "" ||
+		result.Pacts[0].URL != server.URL+"/pacts/provider/Some%20Provider/consumer/billy/version/1.0.0" {
+		t.Fatalf("expected the dry run to be reported, got %+v", result)
+	}
+}
@@ -61,5 +61,6 @@ func (s *ServiceMock) Command() *exec.Cmd {
 
 // NewService creates a new MockService with default settings.
 func (s *ServiceMock) NewService(args []string) client.Service {
+	s.Args = args
 	return s
 }
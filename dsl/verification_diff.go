@@ -0,0 +1,187 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// ANSI escape codes used to color the diff of a failed interaction.
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+	colorReset = "\x1b[0m"
+)
+
+// useColor is true if the output is a terminal, unless disabled with the
+// NO_COLOR environment variable.
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// renderExample describes why an example failed: a diff of the expected (-)
+// and actual (+) responses annotated with each mismatch when they are known,
+// or the sanitised message of the verifier otherwise. If the name of the test
+// of the example is given, it ends with the command to re-run it alone.
+func renderExample(example types.ProviderVerifierExample, test string, color bool) string {
+	var b bytes.Buffer
+
+	if example.Expected != nil && example.Actual != nil {
+		writeDiff(&b, responseLines(example.Expected, example.Expected), responseLines(example.Actual, example.Expected), color)
+
+		if len(example.Mismatches) > 0 {
+			b.WriteString("\nMismatches:\n")
+			for _, mismatch := range example.Mismatches {
+				if mismatch.Path != "" {
+					fmt.Fprintf(&b, "\t%s: %s\n", paint(mismatch.Path, colorCyan, color), mismatch.Message)
+				} else {
+					fmt.Fprintf(&b, "\t%s\n", mismatch.Message)
+				}
+			}
+		}
+	} else {
+		for _, line := range strings.Split(strings.TrimSpace(sanitiseRubyResponse(example.Exception.Message)), "\n") {
+			if rerunLine.MatchString(line) {
+				continue
+			}
+			switch trimmed := strings.TrimSpace(line); {
+			case strings.HasPrefix(trimmed, "-"):
+				line = paint(line, colorRed, color)
+			case strings.HasPrefix(trimmed, "+"):
+				line = paint(line, colorGreen, color)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if test != "" {
+		fmt.Fprintf(&b, "\nTo re-run this interaction alone: go test -run %s\n", rerunPattern(test, example.Description))
+	}
+
+	return b.String()
+}
+
+// rerunLine matches the instructions of the Ruby verifier to re-run an
+// interaction, which are replaced by a go test command.
+var rerunLine = regexp.MustCompile(`PACT_DESCRIPTION=|PACT_PROVIDER_STATE=|To re-run this specific test`)
+
+// rerunPattern is the -run flag of go test matching only the given test,
+// e.g. TestProvider/A_request_for_billy, quoted for the shell.
+func rerunPattern(test string, description string) string {
+	parts := strings.Split(test, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+
+	// go test tells apart subtests with the same name with a suffix e.g.
+	// A_request_for_billy#01, but the interactions are selected by their
+	// description, so the pattern matches all of them
+	if !strings.HasSuffix(test, "/"+subtestName(description)) && duplicateSuffix.MatchString(test) {
+		last := test[strings.LastIndex(test, "/")+1:]
+		parts[len(parts)-1] = "^" + regexp.QuoteMeta(duplicateSuffix.ReplaceAllString(last, "")) + "(#[0-9]+)?$"
+	}
+
+	return "'" + strings.Replace(strings.Join(parts, "/"), "'", `'\''`, -1) + "'"
+}
+
+// duplicateSuffix is added by go test to the names of subtests that are not
+// unique.
+var duplicateSuffix = regexp.MustCompile(`#[0-9]+$`)
+
+// responseLines renders a response as lines of text, with only the headers
+// of the expected response so that others do not clutter the diff.
+func responseLines(res *types.ProviderVerifierHTTPResponse, expected *types.ProviderVerifierHTTPResponse) []string {
+	lines := []string{fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status))}
+
+	headers := make(map[string]string, len(res.Headers))
+	for key, value := range res.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	var names []string
+	for key := range expected.Headers {
+		names = append(names, http.CanonicalHeaderKey(key))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := headers[name]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+		}
+	}
+
+	if res.Body != nil {
+		lines = append(lines, "")
+		body, ok := res.Body.(string)
+		if !ok {
+			data, err := json.MarshalIndent(res.Body, "", "  ")
+			if err != nil {
+				body = fmt.Sprint(res.Body)
+			} else {
+				body = string(data)
+			}
+		}
+		lines = append(lines, strings.Split(body, "\n")...)
+	}
+
+	return lines
+}
+
+// writeDiff writes a unified diff of the expected and actual lines.
+func writeDiff(b *bytes.Buffer, expected []string, actual []string, color bool) {
+	b.WriteString(paint("--- expected", colorRed, color) + "\n")
+	b.WriteString(paint("+++ actual", colorGreen, color) + "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// expected[i:] and actual[j:]
+	lcs := make([][]int, len(expected)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			b.WriteString(" " + expected[i] + "\n")
+			i++
+			j++
+		case j == len(actual) || (i < len(expected) && lcs[i+1][j] >= lcs[i][j+1]):
+			b.WriteString(paint("-"+expected[i], colorRed, color) + "\n")
+			i++
+		default:
+			b.WriteString(paint("+"+actual[j], colorGreen, color) + "\n")
+			j++
+		}
+	}
+}
+
+// paint colors the text, if color is enabled.
+func paint(text string, code string, color bool) string {
+	if !color {
+		return text
+	}
+	return code + text + colorReset
+}
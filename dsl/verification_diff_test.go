@@ -0,0 +1,95 @@
+package dsl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestRenderExample(t *testing.T) {
+	example := types.ProviderVerifierExample{
+		Description: "A request for billy",
+		Status:      "failed",
+		Expected: &types.ProviderVerifierHTTPResponse{
+			Status:  200,
+			Headers: map[string]string{"content-type": "application/json"},
+			Body:    map[string]interface{}{"id": 1, "name": "billy"},
+		},
+		Actual: &types.ProviderVerifierHTTPResponse{
+			Status:  200,
+			Headers: map[string]string{"Content-Type": "application/json", "Date": "Mon, 01 Jan 2018 00:00:00 GMT"},
+			Body:    map[string]interface{}{"id": 1, "name": "sally"},
+		},
+		Mismatches: []types.ProviderVerifierMismatch{
+			{Path: "$.body.name", Expected: "billy", Actual: "sally", Message: "Expected 'billy' but received 'sally'"},
+		},
+	}
+
+	expected := `--- expected
++++ actual
+ 200 OK
+ Content-Type: application/json
+ 
+ {
+   "id": 1,
+-  "name": "billy"
++  "name": "sally"
+ }
+
+Mismatches:
+	$.body.name: Expected 'billy' but received 'sally'
+
+To re-run this interaction alone: go test -run '^TestProvider$/^A_request_for_billy$'
+`
+	if rendered := renderExample(example, "TestProvider/A_request_for_billy", false); rendered != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, rendered)
+	}
+
+	if rendered := renderExample(example, "TestProvider/A_request_for_billy", true); !strings.Contains(rendered, colorRed+`-  "name": "billy"`+colorReset) {
+		t.Fatalf("expected a colored diff, got %q", rendered)
+	}
+}
+
+func TestRenderExampleRuby(t *testing.T) {
+	example := types.ProviderVerifierExample{
+		Status: "failed",
+		Exception: types.ProviderVerifierException{
+			Message: "Actual: {\"name\":\"sally\"}\n\nDiff\n--------------------------------------\nKey: - is expected \n     + is actual \n" +
+				"@@ -1,2 +1,2 @@\n-  \"name\": \"billy\"\n+  \"name\": \"sally\"\n" +
+				"# /usr/local/lib/ruby/pact/provider/rspec.rb:150\n" +
+				"PACT_DESCRIPTION=\"A request for billy\" PACT_PROVIDER_STATE=\"User billy exists\" bundle exec rake pact:verify",
+		},
+	}
+
+	// The Ruby verifier can not re-run a single subtest
+	rendered := renderExample(example, "", false)
+
+	for _, unwanted := range []string{"rspec.rb", "PACT_DESCRIPTION", "go test -run"} {
+		if strings.Contains(rendered, unwanted) {
+			t.Fatalf("expected %s to be removed, got %s", unwanted, rendered)
+		}
+	}
+	if !strings.Contains(rendered, "+  \"name\": \"sally\"") {
+		t.Fatalf("expected the diff, got %s", rendered)
+	}
+}
+
+func Test_rerunPattern(t *testing.T) {
+	tests := []struct {
+		test        string
+		description string
+		expected    string
+	}{
+		{"TestProvider/billy's_request", "billy's request", `'^TestProvider$/^billy'\''s_request$'`},
+		{"TestProvider/A_request_(v2)", "A request (v2)", `'^TestProvider$/^A_request_\(v2\)$'`},
+		{"TestProvider/A_request#01", "A request", `'^TestProvider$/^A_request(#[0-9]+)?$'`},
+		{"TestProvider/Request_#1", "Request #1", `'^TestProvider$/^Request_#1$'`},
+	}
+
+	for _, test := range tests {
+		if pattern := rerunPattern(test.test, test.description); pattern != test.expected {
+			t.Fatalf("expected the pattern %s for %s, got %s", test.expected, test.test, pattern)
+		}
+	}
+}
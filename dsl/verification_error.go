@@ -0,0 +1,177 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VerificationError is returned when the requests received by a mock service
+// do not match the interactions it expected, listing the differences.
+type VerificationError struct {
+	// Missing are the expected requests that were never received.
+	Missing []MissingRequest `json:"missing,omitempty"`
+
+	// Unexpected are the requests received that matched no interaction.
+	Unexpected []UnexpectedRequest `json:"unexpected,omitempty"`
+
+	// message is the error reported by a mock service that does not describe
+	// its errors as JSON, such as the Ruby Mock Service.
+	message string
+}
+
+// MissingRequest is an expected request that was never received.
+type MissingRequest struct {
+	// Description of the interaction, if known.
+	Description string `json:"description,omitempty"`
+
+	// Request that was expected e.g. "GET /users/1".
+	Request string `json:"request"`
+}
+
+// UnexpectedRequest is a request that matched no interaction.
+type UnexpectedRequest struct {
+	// Request that was received e.g. "POST /users".
+	Request string `json:"request"`
+
+	// Mismatches with each interaction for the same method, by the description
+	// of the interaction.
+	Mismatches map[string][]Mismatch `json:"mismatches,omitempty"`
+}
+
+// Error describes the differences in the same format as the Ruby Mock
+// Service.
+func (e *VerificationError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+
+	var b bytes.Buffer
+	b.WriteString("Actual interactions do not match expected interactions for mock MockService.\n")
+
+	if len(e.Missing) > 0 {
+		b.WriteString("\nMissing requests:\n")
+		for _, m := range e.Missing {
+			fmt.Fprintf(&b, "\t%s\n", m.Request)
+		}
+	}
+
+	if len(e.Unexpected) > 0 {
+		b.WriteString("\nUnexpected requests:\n")
+		for _, u := range e.Unexpected {
+			fmt.Fprintf(&b, "\t%s\n", u.Request)
+			for _, description := range sortedMismatchKeys(u.Mismatches) {
+				fmt.Fprintf(&b, "\t\tdiff with '%s':\n", description)
+				for _, mismatch := range u.Mismatches[description] {
+					fmt.Fprintf(&b, "\t\t\t%s\n", mismatch.Message)
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Diff renders the differences as a diff of the expected (-) and actual (+)
+// values of each mismatch, annotated with their location.
+func (e *VerificationError) Diff() string {
+	var b bytes.Buffer
+
+	for _, m := range e.Missing {
+		if m.Description != "" {
+			fmt.Fprintf(&b, "- %s (%s) was expected but not received\n", m.Request, m.Description)
+		} else {
+			fmt.Fprintf(&b, "- %s was expected but not received\n", m.Request)
+		}
+	}
+
+	for _, u := range e.Unexpected {
+		fmt.Fprintf(&b, "+ %s was received but not expected\n", u.Request)
+		for _, description := range sortedMismatchKeys(u.Mismatches) {
+			fmt.Fprintf(&b, "  diff with '%s':\n", description)
+			for _, mismatch := range u.Mismatches[description] {
+				if mismatch.Path == "" {
+					fmt.Fprintf(&b, "    %s\n", mismatch.Message)
+					continue
+				}
+				fmt.Fprintf(&b, "    %s: %s\n", mismatch.Path, mismatch.Message)
+				fmt.Fprintf(&b, "    - %s\n", diffValue(mismatch.Expected))
+				fmt.Fprintf(&b, "    + %s\n", diffValue(mismatch.Actual))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// diffValue renders a value of a mismatch as JSON.
+func diffValue(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// newVerificationError reads the error reported by a mock service, either
+// as JSON from the native mock service or as text from the Ruby Mock Service.
+func newVerificationError(contentType string, body []byte) *VerificationError {
+	if isJSONContentType(contentType) {
+		var e VerificationError
+		if err := json.Unmarshal(body, &e); err == nil && (len(e.Missing) > 0 || len(e.Unexpected) > 0) {
+			return &e
+		}
+	}
+
+	return parseVerificationError(string(body))
+}
+
+// parseVerificationError reads the text of a verification error, such as
+//
+//	Missing requests:
+//		GET /users/1
+//
+//	Unexpected requests:
+//		POST /users/1
+//			diff with 'A request for user 1':
+//				Expected method GET but received POST
+//
+// The Ruby Mock Service also reports "Incorrect requests", which are read as
+// unexpected requests.
+func parseVerificationError(message string) *VerificationError {
+	e := &VerificationError{message: message}
+
+	section := ""
+	var description string
+	for _, line := range strings.Split(message, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case !strings.HasPrefix(line, "\t"):
+			section = strings.TrimSuffix(trimmed, ":")
+		case section == "Missing requests" && !strings.HasPrefix(line, "\t\t"):
+			e.Missing = append(e.Missing, MissingRequest{Request: trimmed})
+		case section == "Unexpected requests" || section == "Incorrect requests":
+			switch {
+			case !strings.HasPrefix(line, "\t\t"):
+				e.Unexpected = append(e.Unexpected, UnexpectedRequest{Request: trimmed})
+			case len(e.Unexpected) == 0:
+			case strings.HasPrefix(trimmed, "diff with '") && strings.HasSuffix(trimmed, "':"):
+				description = strings.TrimSuffix(strings.TrimPrefix(trimmed, "diff with '"), "':")
+			default:
+				u := &e.Unexpected[len(e.Unexpected)-1]
+				if u.Mismatches == nil {
+					u.Mismatches = make(map[string][]Mismatch)
+				}
+				u.Mismatches[description] = append(u.Mismatches[description], Mismatch{Message: trimmed})
+			}
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,89 @@
+package dsl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var rubyVerificationError = `Actual interactions do not match expected interactions for mock MockService.
+
+Missing requests:
+	GET /users/1
+
+Unexpected requests:
+	POST /users/1
+		diff with 'A request for billy':
+			Expected method GET but received POST
+
+Incorrect requests:
+	PUT /users/2 (request body did not match)
+
+See pact-mock-service.log for details.
+`
+
+func TestVerificationError_Parse(t *testing.T) {
+	err := parseVerificationError(rubyVerificationError)
+
+	if err.Error() != rubyVerificationError {
+		t.Fatalf("expected the original message, got %s", err.Error())
+	}
+	if len(err.Missing) != 1 || err.Missing[0].Request != "GET /users/1" {
+		t.Fatalf("expected a missing request, got %+v", err.Missing)
+	}
+	if len(err.Unexpected) != 2 || err.Unexpected[0].Request != "POST /users/1" || err.Unexpected[1].Request != "PUT /users/2 (request body did not match)" {
+		t.Fatalf("expected two unexpected requests, got %+v", err.Unexpected)
+	}
+	mismatches := err.Unexpected[0].Mismatches["A request for billy"]
+	if len(mismatches) != 1 || mismatches[0].Message != "Expected method GET but received POST" {
+		t.Fatalf("expected a method mismatch, got %+v", err.Unexpected[0].Mismatches)
+	}
+}
+
+func TestVerificationError_Diff(t *testing.T) {
+	err := &VerificationError{
+		Missing: []MissingRequest{{Description: "A request for billy", Request: "GET /users/1"}},
+		Unexpected: []UnexpectedRequest{{
+			Request: "POST /users",
+			Mismatches: map[string][]Mismatch{
+				"A request to create billy": {{
+					Type:     "body",
+					Path:     "$.body.name",
+					Expected: "billy",
+					Actual:   "sally",
+					Message:  "Expected 'billy' but received 'sally'",
+				}},
+			},
+		}},
+	}
+
+	expected := `- GET /users/1 (A request for billy) was expected but not received
++ POST /users was received but not expected
+  diff with 'A request to create billy':
+    $.body.name: Expected 'billy' but received 'sally'
+    - "billy"
+    + "sally"
+`
+	if diff := err.Diff(); diff != expected {
+		t.Fatalf("expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+
+	if !strings.Contains(err.Error(), "\t\tdiff with 'A request to create billy':\n\t\t\tExpected 'billy' but received 'sally'") {
+		t.Fatalf("expected the mismatches in the error, got %s", err.Error())
+	}
+}
+
+func TestMockService_VerifyRubyMockService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, rubyVerificationError, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := (&MockService{BaseURL: server.URL}).Verify()
+
+	verr, ok := err.(*VerificationError)
+	if !ok || len(verr.Missing) != 1 || len(verr.Unexpected) != 2 {
+		t.Fatalf("expected a verification error, got %#v", err)
+	}
+}
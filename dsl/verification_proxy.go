@@ -0,0 +1,66 @@
+package dsl
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// hasVerificationHooks reports whether a request has hooks or a request
+// filter to run around each interaction.
+func hasVerificationHooks(request types.VerifyRequest) bool {
+	return request.BeforeEach != nil || request.AfterEach != nil || request.RequestFilter != nil
+}
+
+// verificationProxy is a reverse proxy to the Provider that runs the hooks and
+// request filter of a verification around each replayed request, for the Ruby
+// verifier which is unable to run them itself.
+//
+// As provider states are set up by the Ruby verifier directly, the hooks run
+// around the replayed request only. A hook error is returned to the verifier
+// as a 500, failing the interaction.
+func verificationProxy(target *url.URL, request types.VerifyRequest) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = target.Host
+		if request.RequestFilter != nil {
+			request.RequestFilter(r)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if request.BeforeEach != nil {
+			if err := request.BeforeEach(); err != nil {
+				log.Println("[ERROR] BeforeEach hook failed:", err)
+				http.Error(w, fmt.Sprintf("BeforeEach hook failed: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Hold the response back until the AfterEach hook has run, so that
+		// its failure can be reported instead
+		recorder := httptest.NewRecorder()
+		proxy.ServeHTTP(recorder, r)
+
+		if request.AfterEach != nil {
+			if err := request.AfterEach(); err != nil {
+				log.Println("[ERROR] AfterEach hook failed:", err)
+				http.Error(w, fmt.Sprintf("AfterEach hook failed: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	})
+}
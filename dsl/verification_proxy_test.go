@@ -0,0 +1,78 @@
+package dsl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func TestVerificationProxy(t *testing.T) {
+	var calls []string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls = append(calls, "provider")
+		w.Header().Set("X-Trace-Id", req.Header.Get("X-Trace-Id"))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "billy")
+	}))
+	defer provider.Close()
+	target, _ := url.Parse(provider.URL)
+
+	proxy := httptest.NewServer(verificationProxy(target, types.VerifyRequest{
+		BeforeEach: func() error {
+			calls = append(calls, "before")
+			return nil
+		},
+		AfterEach: func() error {
+			calls = append(calls, "after")
+			return nil
+		},
+		RequestFilter: func(req *http.Request) {
+			req.Header.Set("X-Trace-Id", "1234")
+		},
+	}))
+	defer proxy.Close()
+
+	res, err := http.Get(proxy.URL + "/users/1")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated || string(body) != "billy" || res.Header.Get("X-Trace-Id") != "1234" {
+		t.Fatalf("expected the filtered response from the provider, got %d %s %v", res.StatusCode, body, res.Header)
+	}
+	expected := []string{"before", "provider", "after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func TestVerificationProxy_HookFail(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer provider.Close()
+	target, _ := url.Parse(provider.URL)
+
+	proxy := httptest.NewServer(verificationProxy(target, types.VerifyRequest{
+		AfterEach: func() error {
+			return fmt.Errorf("cache unavailable")
+		},
+	}))
+	defer proxy.Close()
+
+	res, err := http.Get(proxy.URL)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", res.StatusCode)
+	}
+}
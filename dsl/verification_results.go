@@ -0,0 +1,181 @@
+package dsl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/broker"
+	"github.com/pact-foundation/pact-go/types"
+)
+
+// verifyAndPublish verifies the Pacts of a request and, if requested,
+// publishes the results to the Pact Broker. The results are published by
+// pact-go rather than the verifier, so that publishing works the same way
+// with any verifier.
+func (p *Pact) verifyAndPublish(ctx context.Context, request types.VerifyRequest, pending map[string]bool, verify func(types.VerifyRequest) (types.ProviderVerifierResponse, error)) (types.ProviderVerifierResponse, error) {
+	filter, err := newInteractionFilter(request)
+	if err != nil {
+		return types.ProviderVerifierResponse{}, err
+	}
+	if request.PublishVerificationResults && filter.active() {
+		log.Println("[WARN] not publishing verification results, as only some interactions are verified")
+		request.PublishVerificationResults = false
+	}
+
+	if !request.PublishVerificationResults {
+		response, err := verifyPending(request, pending, verify)
+		if err == nil && filter.active() && len(response.Examples) == 0 {
+			err = filter.noMatches()
+		}
+		return response, err
+	}
+
+	if request.ProviderVersion == "" {
+		return types.ProviderVerifierResponse{}, errors.New("ProviderVersion is mandatory to publish verification results")
+	}
+
+	client, err := verificationBrokerClient(ctx, request)
+	if err != nil {
+		return types.ProviderVerifierResponse{}, err
+	}
+
+	// Stop the Ruby verifier from publishing the results too
+	request.PublishVerificationResults = false
+
+	response, err := verifyPending(request, pending, func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return publishVerificationResults(client, request, verify)
+	})
+
+	// The Provider version is only tagged once its results are published
+	for _, tag := range request.ProviderVersionTags {
+		log.Printf("[INFO] tagging version %s of %s with '%s'", request.ProviderVersion, p.Provider, tag)
+		if tagErr := client.TagVersion(p.Provider, request.ProviderVersion, tag); tagErr != nil && err == nil {
+			err = fmt.Errorf("unable to tag version %s of %s: %v", request.ProviderVersion, p.Provider, tagErr)
+		}
+	}
+
+	return response, err
+}
+
+// publishVerificationResults verifies each Pact of a request separately, so
+// that its result can be published to the Pact Broker it was fetched from.
+// Pacts read from disk are verified but have nowhere to be published.
+func publishVerificationResults(client *broker.Client, request types.VerifyRequest, verify func(types.VerifyRequest) (types.ProviderVerifierResponse, error)) (types.ProviderVerifierResponse, error) {
+	var response types.ProviderVerifierResponse
+	var verifyErr, publishErr error
+
+	for _, pactURL := range request.PactURLs {
+		current := request
+		current.PactURLs = []string{pactURL}
+		res, err := verify(current)
+		mergeResponse(&response, res)
+		if err != nil && verifyErr == nil {
+			verifyErr = err
+		}
+
+		if !isURL(pactURL) {
+			log.Println("[DEBUG] not publishing verification results for pact file:", pactURL)
+			continue
+		}
+
+		// Nothing was verified, e.g. the Provider could not be reached
+		if err != nil && len(res.Examples) == 0 {
+			continue
+		}
+
+		result := verificationResult(request, res, err)
+		log.Printf("[INFO] publishing verification result (success: %t) for %s", result.Success, pactURL)
+		if err = client.PublishVerificationResult(pactURL, result); err != nil && publishErr == nil {
+			publishErr = fmt.Errorf("unable to publish verification results for %s: %v", pactURL, err)
+		}
+	}
+
+	if verifyErr != nil {
+		return response, verifyErr
+	}
+
+	return response, publishErr
+}
+
+// verificationResult converts the response from verifying a Pact to the
+// result to publish.
+func verificationResult(request types.VerifyRequest, res types.ProviderVerifierResponse, err error) broker.VerificationResult {
+	result := broker.VerificationResult{
+		Success:                    err == nil,
+		ProviderApplicationVersion: request.ProviderVersion,
+		BuildURL:                   request.BuildURL,
+	}
+
+	testResults := broker.TestResults{}
+	for _, example := range res.Examples {
+		test := broker.TestResult{
+			TestDescription:     example.Description,
+			TestFullDescription: example.FullDescription,
+			Status:              example.Status,
+		}
+		if example.Status != "passed" {
+			result.Success = false
+			test.Exception = &broker.TestException{
+				Class:   example.Exception.Class,
+				Message: example.Exception.Message,
+			}
+		}
+		testResults.Tests = append(testResults.Tests, test)
+	}
+	result.TestResults = testResults
+
+	return result
+}
+
+// mergeResponse adds the examples of a verifier response to another.
+func mergeResponse(response *types.ProviderVerifierResponse, res types.ProviderVerifierResponse) {
+	if res.Version != "" {
+		response.Version = res.Version
+	}
+	response.Examples = append(response.Examples, res.Examples...)
+	response.Summary.Duration += res.Summary.Duration
+	response.Summary.ExampleCount += res.Summary.ExampleCount
+	response.Summary.FailureCount += res.Summary.FailureCount
+	response.Summary.PendingCount += res.Summary.PendingCount
+	response.Summary.ErrorsOutsideOfExamplesCount += res.Summary.ErrorsOutsideOfExamplesCount
+
+	if res.SummaryLine != "" {
+		response.SummaryLine = fmt.Sprintf("%d examples, %d failures, %d pending", response.Summary.ExampleCount, response.Summary.FailureCount, response.Summary.PendingCount)
+	}
+}
+
+// verificationBrokerURL is the Pact Broker to publish verification results
+// to, which defaults to the Broker of the first Pact fetched from a URL. That
+// is the URL of the Pact up to its "/pacts/" path, so that a Broker served
+// under a path prefix keeps it.
+func verificationBrokerURL(request types.VerifyRequest) string {
+	if request.BrokerURL != "" {
+		return request.BrokerURL
+	}
+
+	for _, pactURL := range request.PactURLs {
+		if !isURL(pactURL) {
+			continue
+		}
+		if u, err := url.Parse(pactURL); err == nil {
+			path := u.EscapedPath()
+			if i := strings.Index(path, "/pacts/"); i >= 0 {
+				path = path[:i]
+			} else {
+				path = ""
+			}
+			return u.Scheme + "://" + u.Host + path
+		}
+	}
+
+	return ""
+}
+
+// isURL reports whether a Pact is fetched from a URL rather than read from disk.
+func isURL(pactURL string) bool {
+	return strings.HasPrefix(pactURL, "http://") || strings.HasPrefix(pactURL, "https://")
+}
@@ -0,0 +1,292 @@
+package dsl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/broker"
+	"github.com/pact-foundation/pact-go/types"
+)
+
+func setupVerificationResultsBroker(published *[]broker.VerificationResult, tagged *[]string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/pacts/1":
+			fmt.Fprintf(w, `{"_links":{"pb:publish-verification-results":{"href":"%s/pacts/1/verification-results"}}}`, server.URL)
+		case req.Method == http.MethodPost && req.URL.Path == "/pacts/1/verification-results":
+			var result broker.VerificationResult
+			data, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(data, &result)
+			*published = append(*published, result)
+			fmt.Fprint(w, `{}`)
+		case req.Method == http.MethodPut:
+			*tagged = append(*tagged, req.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return server
+}
+
+func TestPact_verifyAndPublish(t *testing.T) {
+	var published []broker.VerificationResult
+	var tagged []string
+	server := setupVerificationResultsBroker(&published, &tagged)
+	defer server.Close()
+
+	var verified [][]string
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		if request.PublishVerificationResults {
+			t.Fatalf("expected the verifier not to publish the results")
+		}
+		if len(tagged) > 0 {
+			t.Fatalf("expected the provider version to be tagged after the verification")
+		}
+		verified = append(verified, request.PactURLs)
+		return types.ProviderVerifierResponse{
+			Examples: []types.ProviderVerifierExample{
+				{Description: "passes", Status: "passed"},
+				{Description: "fails", Status: "failed", Exception: types.ProviderVerifierException{Message: "boom"}},
+			},
+			Summary:     types.ProviderVerifierSummary{ExampleCount: 2, FailureCount: 1},
+			SummaryLine: "2 examples, 1 failures",
+		}, errors.New("verification failed")
+	}
+
+	pact := &Pact{Provider: "bobby"}
+	res, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{"foo.json", server.URL + "/pacts/1"},
+		BrokerURL:                  server.URL,
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+		ProviderVersionTags:        []string{"master"},
+		BuildURL:                   "http://ci/1",
+	}, nil, verify)
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if !reflect.DeepEqual(verified, [][]string{{"foo.json"}, {server.URL + "/pacts/1"}}) {
+		t.Fatalf("expected each pact to be verified separately, got %v", verified)
+	}
+	if len(res.Examples) != 4 || res.Summary.FailureCount != 2 || res.SummaryLine != "4 examples, 2 failures, 0 pending" {
+		t.Fatalf("expected the responses to be merged, got %+v", res)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("expected the verification result to be published, got %v", published)
+	}
+	if !reflect.DeepEqual(tagged, []string{"/pacticipants/bobby/versions/1.0.0/tags/master"}) {
+		t.Fatalf("expected the provider version to be tagged, got %v", tagged)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("expected 1 verification result to be published, got %d", len(published))
+	}
+	result := published[0]
+	if result.Success || result.ProviderApplicationVersion != "1.0.0" || result.BuildURL != "http://ci/1" {
+		t.Fatalf("unexpected verification result %+v", result)
+	}
+	tests, _ := json.Marshal(result.TestResults)
+	expected := `{"tests":[{"status":"passed","testDescription":"passes","testFullDescription":""},{"exception":{"message":"boom"},"status":"failed","testDescription":"fails","testFullDescription":""}]}`
+	if string(tests) != expected {
+		t.Fatalf("expected test results %s, got %s", expected, tests)
+	}
+}
+
+func TestPact_verifyAndPublishSuccess(t *testing.T) {
+	var published []broker.VerificationResult
+	var tagged []string
+	server := setupVerificationResultsBroker(&published, &tagged)
+	defer server.Close()
+
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{
+			Examples: []types.ProviderVerifierExample{{Description: "passes", Status: "passed"}},
+		}, nil
+	}
+
+	// The Broker defaults to the host of the pact
+	pact := &Pact{Provider: "bobby"}
+	_, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/pacts/1"},
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+	}, nil, verify)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(published) != 1 || !published[0].Success {
+		t.Fatalf("expected a successful verification result to be published, got %+v", published)
+	}
+}
+
+func TestPact_verifyAndPublishFailures(t *testing.T) {
+	var published []broker.VerificationResult
+	var tagged []string
+	server := setupVerificationResultsBroker(&published, &tagged)
+	defer server.Close()
+
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{}, nil
+	}
+
+	pact := &Pact{Provider: "bobby"}
+
+	// No Provider version
+	_, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/pacts/1"},
+		PublishVerificationResults: true,
+	}, nil, verify)
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	// Pact that can't be published to
+	_, err = pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/pacts/2"},
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+	}, nil, verify)
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	// Nothing verified
+	_, err = pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/pacts/1"},
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+	}, nil, func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{}, errors.New("provider unavailable")
+	})
+	if err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if len(published) != 0 {
+		t.Fatalf("expected no verification results to be published, got %+v", published)
+	}
+}
+
+func TestPact_verifyAndPublishFiltered(t *testing.T) {
+	var published []broker.VerificationResult
+	var tagged []string
+	server := setupVerificationResultsBroker(&published, &tagged)
+	defer server.Close()
+
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{
+			Examples: []types.ProviderVerifierExample{{Description: "passes", Status: "passed"}},
+		}, nil
+	}
+
+	pact := &Pact{Provider: "bobby"}
+	_, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/pacts/1"},
+		BrokerURL:                  server.URL,
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+		ProviderVersionTags:        []string{"master"},
+		FilterDescription:          "passes",
+	}, nil, verify)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(published) != 0 || len(tagged) != 0 {
+		t.Fatalf("expected the results of a filtered verification not to be published, got %v %v", published, tagged)
+	}
+}
+
+func TestPact_verifyAndPublishBrokerPrefix(t *testing.T) {
+	var published []broker.VerificationResult
+	var tagged []string
+	pactBroker := setupVerificationResultsBroker(&published, &tagged)
+	defer pactBroker.Close()
+	server := httptest.NewServer(http.StripPrefix("/broker", pactBroker.Config.Handler))
+	defer server.Close()
+
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{
+			Examples: []types.ProviderVerifierExample{{Description: "passes", Status: "passed"}},
+		}, nil
+	}
+
+	pact := &Pact{Provider: "bobby"}
+	_, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{
+		PactURLs:                   []string{server.URL + "/broker/pacts/1"},
+		PublishVerificationResults: true,
+		ProviderVersion:            "1.0.0",
+		ProviderVersionTags:        []string{"master"},
+	}, nil, verify)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("expected the verification result to be published, got %v", published)
+	}
+	if !reflect.DeepEqual(tagged, []string{"/pacticipants/bobby/versions/1.0.0/tags/master"}) {
+		t.Fatalf("expected the provider version to be tagged on the Broker under its path prefix, got %v", tagged)
+	}
+}
+
+func TestVerificationBrokerURL(t *testing.T) {
+	tests := map[string]types.VerifyRequest{
+		"http://broker":         {BrokerURL: "http://broker", PactURLs: []string{"http://other/pacts/1"}},
+		"https://broker":        {PactURLs: []string{"foo.json", "https://broker/pacts/provider/bobby/consumer/billy/latest"}},
+		"https://host/broker":   {PactURLs: []string{"https://host/broker/pacts/provider/bobby/consumer/billy/latest"}},
+		"https://host/my%20pbs": {PactURLs: []string{"https://host/my%20pbs/pacts/1"}},
+		"http://pacts":          {PactURLs: []string{"http://pacts/billy-bobby.json"}},
+		"":                      {PactURLs: []string{"foo.json"}},
+	}
+
+	for expected, request := range tests {
+		if brokerURL := verificationBrokerURL(request); brokerURL != expected {
+			t.Fatalf("expected the Broker URL %q for %v, got %q", expected, request.PactURLs, brokerURL)
+		}
+	}
+}
+
+func TestMergeResponse(t *testing.T) {
+	var response types.ProviderVerifierResponse
+	mergeResponse(&response, types.ProviderVerifierResponse{
+		Summary:     types.ProviderVerifierSummary{ExampleCount: 2, FailureCount: 1},
+		SummaryLine: "2 examples, 1 failures",
+	})
+	mergeResponse(&response, types.ProviderVerifierResponse{
+		Summary:     types.ProviderVerifierSummary{ExampleCount: 1, PendingCount: 1},
+		SummaryLine: "1 examples, 0 failures, 1 pending",
+	})
+
+	if response.SummaryLine != "3 examples, 1 failures, 1 pending" {
+		t.Fatalf("expected the pending count in the summary, got %s", response.SummaryLine)
+	}
+}
+
+func TestPact_verifyAndPublishNoMatches(t *testing.T) {
+	verify := func(request types.VerifyRequest) (types.ProviderVerifierResponse, error) {
+		return types.ProviderVerifierResponse{}, nil
+	}
+
+	pact := &Pact{Provider: "bobby"}
+	_, err := pact.verifyAndPublish(context.Background(), types.VerifyRequest{FilterDescription: "sally"}, nil, verify)
+	if err == nil || err.Error() != "no interactions to verify match the description 'sally'" {
+		t.Fatalf("expected an error as no interactions match, got %v", err)
+	}
+
+	if _, err = pact.verifyAndPublish(context.Background(), types.VerifyRequest{}, nil, verify); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
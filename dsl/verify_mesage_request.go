@@ -2,6 +2,9 @@ package dsl
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/pact-foundation/pact-go/types"
 )
 
 // VerifyMessageRequest contains the verification logic
@@ -16,18 +19,49 @@ type VerifyMessageRequest struct {
 	// Tags to find in Broker for matrix-based testing
 	Tags []string
 
+	// ConsumerVersionSelectors select the Consumer versions whose Pacts are
+	// verified, using the Broker's "pacts for verification" API.
+	// Cannot be used with Tags.
+	ConsumerVersionSelectors []types.ConsumerVersionSelector
+
+	// EnablePending verifies the Pacts the Broker marks as pending without
+	// their failures failing the verification.
+	EnablePending bool
+
+	// IncludeWIPPactsSince also verifies the work in progress Pacts published
+	// since the given time, as pending Pacts.
+	IncludeWIPPactsSince *time.Time
+
+	// ProviderVersionTags are the tags of the Provider version being verified,
+	// which the Broker uses to decide which Pacts are pending. The Provider
+	// version is tagged with them when verification results are published.
+	ProviderVersionTags []string
+
 	// Username when authenticating to a Pact Broker.
 	BrokerUsername string
 
 	// Password when authenticating to a Pact Broker.
 	BrokerPassword string
 
+	// BrokerToken is a bearer token when authenticating to a Pact Broker,
+	// such as a Pactflow API token. Cannot be used with BrokerUsername and
+	// BrokerPassword.
+	BrokerToken string
+
+	// BrokerTransport configures the certificates and proxy used to connect
+	// to a Pact Broker. Optional.
+	BrokerTransport types.BrokerTransport
+
 	// PublishVerificationResults to the Pact Broker.
 	PublishVerificationResults bool
 
 	// ProviderVersion is the semantical version of the Provider API.
 	ProviderVersion string
 
+	// BuildURL links to the build that ran the verification, and is published
+	// with the verification results.
+	BuildURL string
+
 	// MessageHandlers contains a mapped list of message handlers for a provider
 	// that will be rable to produce the correct message format for a given
 	// consumer interaction
@@ -38,6 +72,12 @@ type VerifyMessageRequest struct {
 	// verification step.
 	StateHandlers StateHandlers
 
+	// StateLifecycleHandlers contain a mapped list of message states to
+	// functions that are used to setup a given provider state prior to the
+	// message verification step, and to tear it down once the message has
+	// been produced. They take precedence over StateHandlers for the same state.
+	StateLifecycleHandlers StateLifecycleHandlers
+
 	// Arguments to the VerificationProvider
 	// Deprecated: This will be deleted after the native library replaces Ruby deps.
 	Args []string
@@ -65,6 +105,10 @@ func (v *VerifyMessageRequest) Validate() error {
 		v.Args = append(v.Args, "--broker-password", v.BrokerPassword)
 	}
 
+	if v.BrokerToken != "" {
+		v.Args = append(v.Args, "--broker-token", v.BrokerToken)
+	}
+
 	if v.ProviderVersion != "" {
 		v.Args = append(v.Args, "--provider_app_version", v.ProviderVersion)
 	}
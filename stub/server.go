@@ -0,0 +1,217 @@
+// Package stub serves the responses of the interactions in pact files, as a
+// fake provider for local development and end-to-end environments.
+package stub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pact-foundation/pact-go/dsl"
+)
+
+// DefaultStateHeader is the request header selecting the provider state of
+// the interaction to respond with.
+const DefaultStateHeader = "X-Pact-Provider-State"
+
+// Server responds to requests with the response of the interaction whose
+// request matches, using the matching rules of the pact files.
+type Server struct {
+	// StateHeader is the request header selecting the provider state of the
+	// interaction to respond with, when several interactions match a request.
+	// Defaults to DefaultStateHeader.
+	StateHeader string
+
+	// ProviderState is the provider state used for requests without the
+	// StateHeader. Optional.
+	ProviderState string
+
+	interactions []*interaction
+	server       *httptest.Server
+}
+
+// interaction is an interaction of a pact file.
+type interaction struct {
+	*dsl.PactInteraction
+
+	// pact is the name of the pact file, to report the matched interaction.
+	pact string
+}
+
+// NewServer creates a stub server for the interactions of the given pact
+// files. Directories are expanded to the *.json files they contain.
+func NewServer(pactFiles ...string) (*Server, error) {
+	files, err := expandPactFiles(pactFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{}
+	for _, file := range files {
+		pact, err := dsl.ReadPactFile(file)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("[DEBUG] stub server: loaded %d interactions from %s", len(pact.Interactions), file)
+		for _, i := range pact.Interactions {
+			s.interactions = append(s.interactions, &interaction{PactInteraction: i, pact: filepath.Base(file)})
+		}
+	}
+
+	if len(s.interactions) == 0 {
+		return nil, errors.New("no interactions found in the pact files")
+	}
+
+	return s, nil
+}
+
+// expandPactFiles expands directories to the pact files they contain.
+func expandPactFiles(pactFiles []string) ([]string, error) {
+	if len(pactFiles) == 0 {
+		return nil, errors.New("at least one pact file is required")
+	}
+
+	var files []string
+	for _, file := range pactFiles {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, file)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(file, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+
+	return files, nil
+}
+
+// Interactions returns the number of interactions the server responds to.
+func (s *Server) Interactions() int {
+	return len(s.interactions)
+}
+
+// Start the stub server listening on the given network address,
+// e.g. "localhost:1234".
+func (s *Server) Start(network string, address string) error {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("unable to start stub server on %s: %v", address, err)
+	}
+
+	s.server = httptest.NewUnstartedServer(s)
+	s.server.Listener.Close()
+	s.server.Listener = ln
+	s.server.Start()
+	log.Println("[DEBUG] stub server listening on:", s.server.URL)
+
+	return nil
+}
+
+// URL is the base URL of the running stub server.
+func (s *Server) URL() string {
+	if s.server == nil {
+		return ""
+	}
+	return s.server.URL
+}
+
+// Stop the stub server.
+func (s *Server) Stop() {
+	if s.server != nil {
+		log.Println("[DEBUG] stopping stub server:", s.server.URL)
+		s.server.Close()
+		s.server = nil
+	}
+}
+
+// ServeHTTP responds with the first interaction matching the request, in
+// the provider state of the request if it has one.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	header := s.StateHeader
+	if header == "" {
+		header = DefaultStateHeader
+	}
+	state := r.Header.Get(header)
+	if state == "" {
+		state = s.ProviderState
+	}
+
+	description := fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+	mismatches := make(map[string][]dsl.Mismatch)
+	var matched []*interaction
+	for _, i := range s.interactions {
+		diff := i.Request.Mismatches(r, body)
+		if len(diff) == 0 {
+			matched = append(matched, i)
+		} else if dsl.OnlyMismatches(diff, "query", "header", "body") {
+			mismatches[i.Description] = diff
+		}
+	}
+
+	if state != "" {
+		var inState []*interaction
+		for _, i := range matched {
+			if hasState(i.PactInteraction, state) {
+				inState = append(inState, i)
+			}
+		}
+		matched = inState
+	}
+
+	if len(matched) == 0 {
+		message := fmt.Sprintf("No interaction found for %s", description)
+		if state != "" {
+			message += fmt.Sprintf(" in provider state '%s'", state)
+		}
+		log.Printf("[WARN] stub server: %s", message)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           message,
+			"interaction_diffs": mismatches,
+		})
+		return
+	}
+
+	if len(matched) > 1 {
+		log.Printf("[DEBUG] stub server: %d interactions match '%s', set the %s header to choose one", len(matched), description, header)
+	}
+	i := matched[0]
+	log.Printf("[INFO] stub server: matched '%s' to interaction '%s' of %s", description, i.Description, i.pact)
+	i.Response.Generate().Send(w)
+}
+
+// hasState reports whether an interaction is in the given provider state.
+func hasState(i *dsl.PactInteraction, state string) bool {
+	for _, s := range i.ProviderStates {
+		if s.Name == state {
+			return true
+		}
+	}
+
+	return false
+}
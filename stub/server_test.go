@@ -0,0 +1,203 @@
+package stub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var userPact = `{
+	"consumer": {"name": "billy"},
+	"provider": {"name": "bobby"},
+	"interactions": [{
+		"description": "A request for an existing user",
+		"providerStates": [{"name": "User sally exists"}],
+		"request": {
+			"method": "GET",
+			"path": "/users/1",
+			"matchingRules": {"path": {"matchers": [{"match": "regex", "regex": "/users/[0-9]+"}]}}
+		},
+		"response": {
+			"status": 200,
+			"headers": {"Content-Type": "application/json"},
+			"body": {"id": 1, "name": "sally"},
+			"generators": {"body": {"$.id": {"type": "RandomInt", "min": 100, "max": 200}}}
+		}
+	}, {
+		"description": "A request for a missing user",
+		"providerStates": [{"name": "No users exist"}],
+		"request": {"method": "GET", "path": "/users/1"},
+		"response": {"status": 404}
+	}],
+	"metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+var orderPact = `{
+	"consumer": {"name": "billy"},
+	"provider": {"name": "olly"},
+	"interactions": [{
+		"description": "A request to create an order",
+		"request": {"method": "POST", "path": "/orders", "headers": {"Content-Type": "application/json"}, "body": {"item": "socks"}},
+		"response": {"status": 201}
+	}],
+	"metadata": {"pactSpecification": {"version": "2.0.0"}}
+}`
+
+func writePacts(t *testing.T, pacts map[string]string) string {
+	dir, err := ioutil.TempDir("", "pact-go")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	for name, pact := range pacts {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(pact), 0644); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+
+	return dir
+}
+
+func setupServer(t *testing.T) (*Server, string) {
+	dir := writePacts(t, map[string]string{"billy-bobby.json": userPact, "billy-olly.json": orderPact})
+	s, err := NewServer(dir)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+
+	return s, dir
+}
+
+func serve(s *Server, method string, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	s, dir := setupServer(t)
+	defer os.RemoveAll(dir)
+
+	if s.Interactions() != 3 {
+		t.Fatalf("expected 3 interactions, got %d", s.Interactions())
+	}
+
+	// The first matching interaction, with generated values
+	w := serve(s, http.MethodGet, "/users/42", nil, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var user map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if id := user["id"].(float64); id < 100 || id > 200 || user["name"] != "sally" {
+		t.Fatalf("expected a generated user, got %v", user)
+	}
+
+	w = serve(s, http.MethodPost, "/orders", map[string]string{"Content-Type": "application/json"}, `{"item":"socks"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+}
+
+func TestServer_ServeHTTPProviderState(t *testing.T) {
+	s, dir := setupServer(t)
+	defer os.RemoveAll(dir)
+
+	w := serve(s, http.MethodGet, "/users/1", map[string]string{DefaultStateHeader: "No users exist"}, "")
+	if w.Code != http.StatusNotFound || w.Body.Len() != 0 {
+		t.Fatalf("expected the missing user interaction, got %d %s", w.Code, w.Body.String())
+	}
+
+	// The state applies to requests without the header
+	s.ProviderState = "No users exist"
+	if w = serve(s, http.MethodGet, "/users/1", nil, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+
+	// The header can be renamed
+	s.StateHeader = "X-State"
+	if w = serve(s, http.MethodGet, "/users/1", map[string]string{"X-State": "User sally exists"}, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	// No interaction in the state
+	w = serve(s, http.MethodGet, "/users/1", map[string]string{"X-State": "Sally is on holiday"}, "")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "in provider state 'Sally is on holiday'") {
+		t.Fatalf("expected no interaction to be found, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServer_ServeHTTPNoMatch(t *testing.T) {
+	s, dir := setupServer(t)
+	defer os.RemoveAll(dir)
+
+	w := serve(s, http.MethodPost, "/orders", map[string]string{"Content-Type": "application/json"}, `{"item":"hats"}`)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+
+	var res struct {
+		Message          string                              `json:"message"`
+		InteractionDiffs map[string][]map[string]interface{} `json:"interaction_diffs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if res.Message != "No interaction found for POST /orders" || len(res.InteractionDiffs["A request to create an order"]) != 1 {
+		t.Fatalf("expected the differences to the order interaction, got %+v", res)
+	}
+}
+
+func TestServer_Start(t *testing.T) {
+	s, dir := setupServer(t)
+	defer os.RemoveAll(dir)
+
+	if err := s.Start("tcp", "localhost:0"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer s.Stop()
+
+	res, err := http.Get(s.URL() + "/users/1")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	s.Stop()
+	if s.URL() != "" {
+		t.Fatalf("expected the server to be stopped")
+	}
+}
+
+func TestNewServerFail(t *testing.T) {
+	if _, err := NewServer(); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	if _, err := NewServer("/does/not/exist.json"); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+
+	dir := writePacts(t, map[string]string{"invalid.json": "{", "empty.json": `{"consumer":{"name":"billy"},"provider":{"name":"bobby"}}`})
+	defer os.RemoveAll(dir)
+	if _, err := NewServer(filepath.Join(dir, "invalid.json")); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+	if _, err := NewServer(filepath.Join(dir, "empty.json")); err == nil {
+		t.Fatalf("Expected error but got none")
+	}
+}
@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// BrokerTransport configures how to connect to a Pact Broker, such as the
+// certificates to trust, the proxy to use and how to retry failed requests.
+// The zero value uses the system certificates and the HTTP_PROXY and
+// HTTPS_PROXY environment variables, and does not retry.
+type BrokerTransport struct {
+	// CACertFile is a PEM bundle of CA certificates to trust, in addition to
+	// the system certificates.
+	CACertFile string
+
+	// ClientCertFile is a PEM client certificate to authenticate to the Pact
+	// Broker with. Requires ClientKeyFile.
+	ClientCertFile string
+
+	// ClientKeyFile is the PEM private key of the client certificate.
+	ClientKeyFile string
+
+	// InsecureSkipVerify disables verification of the Pact Broker's
+	// certificate. Only use it for testing.
+	InsecureSkipVerify bool
+
+	// ProxyURL is the proxy to connect to the Pact Broker through.
+	ProxyURL string
+
+	// Timeout of each request to the Pact Broker. Defaults to no timeout.
+	Timeout time.Duration
+
+	// MaxRetries of a request that fails with a connection error or a 5xx
+	// response. Only idempotent requests, such as publishing a Pact, are
+	// retried.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, which doubles with
+	// each retry. Defaults to 1 second.
+	RetryBackoff time.Duration
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CanIDeploySelector selects a version of a Pacticipant to check.
+type CanIDeploySelector struct {
+	// Pacticipant is the name of the application. Required.
+	Pacticipant string
+
+	// Version of the Pacticipant. Either Version or Latest is required.
+	Version string
+
+	// Latest selects the latest version of the Pacticipant, or the latest
+	// version with the Tag if one is given.
+	Latest bool
+
+	// Tag of the latest version to select. Optional.
+	Tag string
+}
+
+// CanIDeployRequest contains the details required to ask a Pact Broker
+// whether a set of application versions are safe to deploy together.
+type CanIDeployRequest struct {
+	// Pact Broker URL. Required.
+	BrokerURL string
+
+	// Username when authenticating to a Pact Broker.
+	BrokerUsername string
+
+	// Password when authenticating to a Pact Broker.
+	BrokerPassword string
+
+	// BrokerToken is a bearer token when authenticating to a Pact Broker,
+	// such as a Pactflow API token. Cannot be used with BrokerUsername and
+	// BrokerPassword.
+	BrokerToken string
+
+	// BrokerTransport configures the certificates and proxy used to connect
+	// to a Pact Broker. Optional.
+	BrokerTransport BrokerTransport
+
+	// Selectors for the Pacticipant versions to check. Required.
+	Selectors []CanIDeploySelector
+
+	// To is the tag of the environment the versions will be deployed to
+	// e.g. "prod". They are checked against the latest versions of the other
+	// Pacticipants with the tag. Optional.
+	To string
+
+	// RetryWhileUnknown is the number of times to retry while there are
+	// verification results still unknown, e.g. while a provider build is
+	// running.
+	RetryWhileUnknown int
+
+	// RetryInterval is the time to wait between retries. Defaults to 10s.
+	RetryInterval time.Duration
+}
+
+// Validate checks that the minimum fields are provided.
+func (r *CanIDeployRequest) Validate() error {
+	if r.BrokerURL == "" {
+		return errors.New("BrokerURL is mandatory")
+	}
+
+	if len(r.Selectors) == 0 {
+		return errors.New("at least one Selector is mandatory")
+	}
+
+	for _, selector := range r.Selectors {
+		if selector.Pacticipant == "" {
+			return errors.New("Pacticipant is mandatory for each Selector")
+		}
+		if selector.Version == "" && !selector.Latest {
+			return fmt.Errorf("one of Version or Latest is mandatory for '%s'", selector.Pacticipant)
+		}
+	}
+
+	if (r.BrokerUsername != "" && r.BrokerPassword == "") || (r.BrokerUsername == "" && r.BrokerPassword != "") {
+		return errors.New("Must provide both or none of BrokerUsername and BrokerPassword")
+	}
+
+	if r.RetryInterval == 0 {
+		r.RetryInterval = 10 * time.Second
+	}
+
+	return nil
+}
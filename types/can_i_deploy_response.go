@@ -0,0 +1,42 @@
+package types
+
+// CanIDeployResponse is the answer of a Pact Broker as to whether a set of
+// application versions are safe to deploy together.
+type CanIDeployResponse struct {
+	// Deployable is true if all of the versions have been successfully
+	// verified against each other.
+	Deployable bool `json:"deployable"`
+
+	// Reason given by the Broker for the answer.
+	Reason string `json:"reason"`
+
+	// Summary counts the verification results.
+	Summary CanIDeploySummary `json:"summary"`
+
+	// Matrix contains each Consumer and Provider version pair that was
+	// checked.
+	Matrix []CanIDeployResult `json:"matrix"`
+}
+
+// CanIDeploySummary counts the verification results of a can-i-deploy check.
+type CanIDeploySummary struct {
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	Unknown int `json:"unknown"`
+}
+
+// CanIDeployResult is the verification status of a Consumer and Provider
+// version pair.
+type CanIDeployResult struct {
+	Consumer        string `json:"consumer"`
+	ConsumerVersion string `json:"consumerVersion"`
+	Provider        string `json:"provider"`
+	ProviderVersion string `json:"providerVersion,omitempty"`
+
+	// Status is one of "success", "failed" or "unknown" if the Pact has not
+	// been verified.
+	Status string `json:"status"`
+
+	// VerifiedAt is the time of the verification, if any.
+	VerifiedAt string `json:"verifiedAt,omitempty"`
+}
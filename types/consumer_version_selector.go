@@ -0,0 +1,39 @@
+package types
+
+// ConsumerVersionSelector selects the Consumer versions whose Pacts are to be
+// verified, when fetching Pacts from a Pact Broker.
+//
+// e.g. the versions deployed to production are selected with
+// ConsumerVersionSelector{Tag: "prod"}, and the latest version of the main
+// branch with ConsumerVersionSelector{Branch: "main", Latest: true}.
+type ConsumerVersionSelector struct {
+	// Tag of the Consumer versions e.g. "prod".
+	Tag string `json:"tag,omitempty"`
+
+	// FallbackTag is used when no version has the Tag e.g. to fall back to
+	// "main" for a feature branch that does not exist for a Consumer.
+	FallbackTag string `json:"fallbackTag,omitempty"`
+
+	// Latest selects only the latest of the versions, rather than all of them.
+	Latest bool `json:"latest,omitempty"`
+
+	// Consumer limits the selector to the versions of a single Consumer.
+	Consumer string `json:"consumer,omitempty"`
+
+	// Branch of the Consumer versions e.g. "main".
+	Branch string `json:"branch,omitempty"`
+
+	// MainBranch selects the versions of each Consumer's main branch.
+	MainBranch bool `json:"mainBranch,omitempty"`
+
+	// Deployed selects the versions currently deployed to any environment,
+	// or to the Environment if one is given.
+	Deployed bool `json:"deployed,omitempty"`
+
+	// Released selects the versions currently released and supported in any
+	// environment, or in the Environment if one is given.
+	Released bool `json:"released,omitempty"`
+
+	// Environment the versions are deployed to or released in.
+	Environment string `json:"environment,omitempty"`
+}
@@ -0,0 +1,11 @@
+package types
+
+import "net/http"
+
+// Hook is a function that is run around the verification of each
+// interaction e.g. to reset caches between interactions.
+type Hook func() error
+
+// RequestFilter modifies a request before it is replayed against the
+// Provider e.g. to add a short-lived auth token, a trace ID or a signature.
+type RequestFilter func(*http.Request)
@@ -4,12 +4,48 @@ package types
 // This is generally provided as a request to an HTTP endpoint (e.g. PUT /state)
 // to configure a state on a Provider.
 type ProviderState struct {
-	Consumer string   `json:"consumer"`
-	State    string   `json:"state"`
-	States   []string `json:"states"`
+	Consumer string                 `json:"consumer"`
+	State    string                 `json:"state"`
+	States   []string               `json:"states"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Action   StateAction            `json:"action,omitempty"`
 }
 
 // ProviderStates is mapping of consumers to all known states. This is usually
 // a response from an HTTP endpoint (e.g. GET /states) to find all states a
 // provider has.
 type ProviderStates map[string][]string
+
+// State specifies how the system should be configured when
+// verified. e.g. "user A exists"
+type State struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// StateHandler is a provider function that sets up a given state before
+// the provider interaction is validated
+type StateHandler func(State) error
+
+// StateHandlers is a list of StateHandler's
+type StateHandlers map[string]StateHandler
+
+// StateAction is the stage of a provider state's lifecycle, which is set up
+// before an interaction is verified and torn down afterwards.
+type StateAction string
+
+const (
+	// StateSetup sets up a provider state before an interaction is verified.
+	StateSetup StateAction = "setup"
+
+	// StateTeardown tears down a provider state after an interaction is
+	// verified.
+	StateTeardown StateAction = "teardown"
+)
+
+// StateLifecycleHandler is a provider function that sets up a given state
+// before the provider interaction is validated, and tears it down afterwards
+type StateLifecycleHandler func(StateAction, State) error
+
+// StateLifecycleHandlers is a list of StateLifecycleHandler's
+type StateLifecycleHandlers map[string]StateLifecycleHandler
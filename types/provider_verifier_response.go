@@ -3,28 +3,67 @@ package types
 // ProviderVerifierResponse contains the ouput of the pact-provider-verifier
 // command.
 type ProviderVerifierResponse struct {
-	Version  string `json:"version"`
-	Examples []struct {
-		ID              string      `json:"id"`
-		Description     string      `json:"description"`
-		FullDescription string      `json:"full_description"`
-		Status          string      `json:"status"`
-		FilePath        string      `json:"file_path"`
-		LineNumber      int         `json:"line_number"`
-		RunTime         float64     `json:"run_time"`
-		PendingMessage  interface{} `json:"pending_message"`
-		Exception       struct {
-			Class     string   `json:"class"`
-			Message   string   `json:"message"`
-			Backtrace []string `json:"backtrace"`
-		} `json:"exception,omitempty"`
-	} `json:"examples"`
-	Summary struct {
-		Duration                     float64 `json:"duration"`
-		ExampleCount                 int     `json:"example_count"`
-		FailureCount                 int     `json:"failure_count"`
-		PendingCount                 int     `json:"pending_count"`
-		ErrorsOutsideOfExamplesCount int     `json:"errors_outside_of_examples_count"`
-	} `json:"summary"`
-	SummaryLine string `json:"summary_line"`
+	Version     string                    `json:"version"`
+	Examples    []ProviderVerifierExample `json:"examples"`
+	Summary     ProviderVerifierSummary   `json:"summary"`
+	SummaryLine string                    `json:"summary_line"`
+}
+
+// ProviderVerifierExample is the result of verifying a single interaction.
+type ProviderVerifierExample struct {
+	ID              string                    `json:"id"`
+	Description     string                    `json:"description"`
+	FullDescription string                    `json:"full_description"`
+	Status          string                    `json:"status"`
+	FilePath        string                    `json:"file_path"`
+	LineNumber      int                       `json:"line_number"`
+	RunTime         float64                   `json:"run_time"`
+	PendingMessage  interface{}               `json:"pending_message"`
+	Exception       ProviderVerifierException `json:"exception,omitempty"`
+
+	// Pending is true if the interaction is from a pending Pact, so that a
+	// failure to verify it does not fail the verification.
+	Pending bool `json:"pending,omitempty"`
+
+	// Expected and Actual are the responses of an interaction that did not
+	// match, when verified by the native verifier.
+	Expected *ProviderVerifierHTTPResponse `json:"expected,omitempty"`
+	Actual   *ProviderVerifierHTTPResponse `json:"actual,omitempty"`
+
+	// Mismatches between the Expected and Actual responses.
+	Mismatches []ProviderVerifierMismatch `json:"mismatches,omitempty"`
+}
+
+// ProviderVerifierHTTPResponse is a response of the Provider, or the one
+// expected of it.
+type ProviderVerifierHTTPResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// ProviderVerifierMismatch is a difference between the expected and actual
+// responses.
+type ProviderVerifierMismatch struct {
+	// Path is the location of the mismatch e.g. "$.body.name".
+	Path     string      `json:"path,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// ProviderVerifierException describes why an example failed.
+type ProviderVerifierException struct {
+	Class     string   `json:"class"`
+	Message   string   `json:"message"`
+	Backtrace []string `json:"backtrace"`
+}
+
+// ProviderVerifierSummary contains the totals of a verification run.
+type ProviderVerifierSummary struct {
+	Duration                     float64 `json:"duration"`
+	ExampleCount                 int     `json:"example_count"`
+	FailureCount                 int     `json:"failure_count"`
+	PendingCount                 int     `json:"pending_count"`
+	ErrorsOutsideOfExamplesCount int     `json:"errors_outside_of_examples_count"`
 }
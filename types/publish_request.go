@@ -2,7 +2,9 @@ package types
 
 // PublishRequest contains the details required to Publish Pacts to a broker.
 type PublishRequest struct {
-	// Array of local Pact files or directories containing them. Required.
+	// Array of local Pact files, directories containing them or glob patterns
+	// such as "pacts/*-provider.json". Directories are expanded to the *.json
+	// files they contain. Required.
 	PactURLs []string
 
 	// URL to fetch the provider states for the given provider API. Optional.
@@ -14,10 +16,33 @@ type PublishRequest struct {
 	// Password for Pact Broker basic authentication. Optional
 	BrokerPassword string
 
+	// BrokerToken is a bearer token when authenticating to a Pact Broker,
+	// such as a Pactflow API token. Cannot be used with BrokerUsername and
+	// BrokerPassword.
+	BrokerToken string
+
+	// BrokerTransport configures the certificates and proxy used to connect
+	// to a Pact Broker. Optional.
+	BrokerTransport BrokerTransport
+
 	// ConsumerVersion is the semantical version of the consumer API.
 	ConsumerVersion string
 
 	// Tags help you organise your Pacts for different testing purposes.
 	// e.g. "production", "latest" and "development" are some common examples.
 	Tags []string
+
+	// DisableGitBranchTag stops the Pacts also being tagged with the current
+	// git branch, which they are by default when it can be found.
+	DisableGitBranchTag bool
+
+	// Branch of the consumer the Pacts were built from. Optional.
+	Branch string
+
+	// BuildURL links to the build that produced the Pacts. Optional.
+	BuildURL string
+
+	// DryRun logs the requests that would be sent to the Pact Broker, with
+	// the digests of their payloads, instead of sending them.
+	DryRun bool
 }
@@ -0,0 +1,46 @@
+package types
+
+// PublishResult is the outcome of publishing Pacts to a Pact Broker, with
+// the result of each Pact file.
+type PublishResult struct {
+	// DryRun is true if nothing was sent to the Pact Broker.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	Pacts []PactPublishResult `json:"pacts"`
+}
+
+// PactPublishResult is the outcome of publishing a single Pact file.
+type PactPublishResult struct {
+	// File is the path or URL of the Pact file.
+	File string `json:"file"`
+
+	// Consumer of the Pact, once the file has been read.
+	Consumer string `json:"consumer,omitempty"`
+
+	// Provider of the Pact, once the file has been read.
+	Provider string `json:"provider,omitempty"`
+
+	// URL of the Pact in the Pact Broker.
+	URL string `json:"url,omitempty"`
+
+	// Digest of the published contents of the Pact.
+	Digest string `json:"digest,omitempty"`
+
+	// Tags applied to the Consumer version of the Pact.
+	Tags []string `json:"tags,omitempty"`
+
+	// Error is why the Pact could not be published, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Failed returns the results of the Pacts that could not be published.
+func (r PublishResult) Failed() []PactPublishResult {
+	var failed []PactPublishResult
+	for _, pact := range r.Pacts {
+		if pact.Error != "" {
+			failed = append(failed, pact)
+		}
+	}
+
+	return failed
+}
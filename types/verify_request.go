@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"log"
+	"time"
 )
 
 // VerifyRequest contains the verification params.
@@ -19,6 +20,27 @@ type VerifyRequest struct {
 	// Tags to find in Broker for matrix-based testing
 	Tags []string
 
+	// ConsumerVersionSelectors select the Consumer versions whose Pacts are
+	// verified, using the Broker's "pacts for verification" API. Exactly the
+	// set of Pacts returned by the Broker is verified.
+	// Cannot be used with Tags.
+	ConsumerVersionSelectors []ConsumerVersionSelector
+
+	// EnablePending verifies the Pacts the Broker marks as pending, i.e. those
+	// with expectations the Provider has never successfully verified, without
+	// their failures failing the verification.
+	EnablePending bool
+
+	// IncludeWIPPactsSince also verifies the work in progress Pacts published
+	// since the given time, as pending Pacts.
+	IncludeWIPPactsSince *time.Time
+
+	// ProviderVersionTags are the tags of the Provider version being verified
+	// e.g. the branch, which the Broker uses to decide which Pacts are pending.
+	// The Provider version is tagged with them when verification results are
+	// published.
+	ProviderVersionTags []string
+
 	// URL to retrieve valid Provider States.
 	// Deprecation notice: no longer valid/required
 	ProviderStatesURL string
@@ -26,18 +48,44 @@ type VerifyRequest struct {
 	// URL to post currentp provider state to on the Provider API.
 	ProviderStatesSetupURL string
 
+	// StateHandlers contain a mapped list of provider states to functions
+	// that are used to setup a given provider state prior to the verification
+	// of each interaction. A state without a handler fails the verification.
+	// Cannot be used with ProviderStatesSetupURL.
+	StateHandlers StateHandlers
+
+	// StateLifecycleHandlers contain a mapped list of provider states to
+	// functions that are used to setup a given provider state prior to the
+	// verification of each interaction, and to tear it down afterwards.
+	// They take precedence over StateHandlers for the same state.
+	// Cannot be used with ProviderStatesSetupURL.
+	StateLifecycleHandlers StateLifecycleHandlers
+
 	// Username when authenticating to a Pact Broker.
 	BrokerUsername string
 
 	// Password when authenticating to a Pact Broker.
 	BrokerPassword string
 
+	// BrokerToken is a bearer token when authenticating to a Pact Broker,
+	// such as a Pactflow API token. Cannot be used with BrokerUsername and
+	// BrokerPassword.
+	BrokerToken string
+
+	// BrokerTransport configures the certificates and proxy used to connect
+	// to a Pact Broker. Optional.
+	BrokerTransport BrokerTransport
+
 	// PublishVerificationResults to the Pact Broker.
 	PublishVerificationResults bool
 
 	// ProviderVersion is the semantical version of the Provider API.
 	ProviderVersion string
 
+	// BuildURL links to the build that ran the verification, and is published
+	// with the verification results.
+	BuildURL string
+
 	// Verbose increases verbosity of output
 	// Deprecated
 	Verbose bool
@@ -48,6 +96,37 @@ type VerifyRequest struct {
 	// in the contract (e.g. time-bound tokens)
 	CustomProviderHeaders []string
 
+	// BeforeEach is run before each interaction is verified, prior to the
+	// setup of its provider states. An error fails the interaction.
+	BeforeEach Hook
+
+	// AfterEach is run after each interaction is verified, once its provider
+	// states have been torn down. An error fails the interaction.
+	AfterEach Hook
+
+	// FilterDescription is a regular expression selecting the interactions to
+	// verify by their description, or the name of their subtest e.g.
+	// "^A_request_for_billy$". Defaults to the PACT_DESCRIPTION environment
+	// variable. The Ruby verifier only verifies a single description, so it
+	// must match only one. Verification fails if no interaction matches.
+	FilterDescription string
+
+	// FilterState is a regular expression selecting the interactions to verify
+	// by their provider states. Defaults to the PACT_PROVIDER_STATE
+	// environment variable. The Ruby verifier only verifies a single provider
+	// state, so it must match only one.
+	FilterState string
+
+	// FilterConsumers selects the Pacts to verify by the name of their
+	// Consumer.
+	FilterConsumers []string
+
+	// RequestFilter is applied to each request replayed against the Provider,
+	// allowing headers such as time-bound tokens to be set per request.
+	// NOTE: As with CustomProviderHeaders, anything it changes is not captured
+	// in the contract.
+	RequestFilter RequestFilter
+
 	// Arguments to the VerificationProvider
 	// Deprecated: This will be deleted after the native library replaces Ruby deps.
 	Args []string
@@ -88,6 +167,10 @@ func (v *VerifyRequest) Validate() error {
 		v.Args = append(v.Args, "--provider-states-url", v.ProviderStatesURL)
 	}
 
+	if v.BrokerToken != "" && (v.BrokerUsername != "" || v.BrokerPassword != "") {
+		return fmt.Errorf("BrokerToken cannot be used with BrokerUsername and BrokerPassword")
+	}
+
 	if v.BrokerUsername != "" {
 		v.Args = append(v.Args, "--broker-username", v.BrokerUsername)
 	}
@@ -96,6 +179,10 @@ func (v *VerifyRequest) Validate() error {
 		v.Args = append(v.Args, "--broker-password", v.BrokerPassword)
 	}
 
+	if v.BrokerToken != "" {
+		v.Args = append(v.Args, "--broker-token", v.BrokerToken)
+	}
+
 	if v.ProviderVersion != "" {
 		v.Args = append(v.Args, "--provider_app_version", v.ProviderVersion)
 	}